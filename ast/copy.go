@@ -0,0 +1,168 @@
+package ast
+
+import "math/big"
+
+// Copy returns a deep copy of node, so it can be modified without changing
+// the original tree.
+func Copy(node Node) Node {
+	switch node := node.(type) {
+	case *Program:
+		statements := make([]Statement, len(node.Statements))
+		for i, statement := range node.Statements {
+			statements[i] = copyStatement(statement)
+		}
+		return &Program{Statements: statements}
+
+	case *ExpressionStatement:
+		return &ExpressionStatement{Token: node.Token, Expression: copyExpression(node.Expression)}
+
+	case *LetStatement:
+		return &LetStatement{Token: node.Token, Name: copyIdentifier(node.Name), Value: copyExpression(node.Value)}
+
+	case *ReturnStatement:
+		return &ReturnStatement{Token: node.Token, ReturnValue: copyExpression(node.ReturnValue)}
+
+	case *BlockStatement:
+		return copyBlock(node)
+
+	case *Identifier:
+		return copyIdentifier(node)
+
+	case *IntegerLiteral:
+		copied := *node
+		return &copied
+
+	case *BigIntegerLiteral:
+		return &BigIntegerLiteral{Token: node.Token, Value: new(big.Int).Set(node.Value)}
+
+	case *StringLiteral:
+		copied := *node
+		return &copied
+
+	case *Boolean:
+		copied := *node
+		return &copied
+
+	case *PrefixExpression:
+		return &PrefixExpression{Token: node.Token, Operator: node.Operator, Right: copyExpression(node.Right)}
+
+	case *InfixExpression:
+		return &InfixExpression{
+			Token:    node.Token,
+			Left:     copyExpression(node.Left),
+			Operator: node.Operator,
+			Right:    copyExpression(node.Right),
+		}
+
+	case *IndexExpression:
+		return &IndexExpression{Token: node.Token, Left: copyExpression(node.Left), Index: copyExpression(node.Index)}
+
+	case *IfExpression:
+		return &IfExpression{
+			Token:       node.Token,
+			Condition:   copyExpression(node.Condition),
+			Consequence: copyBlock(node.Consequence),
+			Alternative: copyBlock(node.Alternative),
+		}
+
+	case *ForExpression:
+		return &ForExpression{
+			Token:    node.Token,
+			Variable: copyIdentifier(node.Variable),
+			Iterable: copyExpression(node.Iterable),
+			Body:     copyBlock(node.Body),
+		}
+
+	case *FunctionLiteral:
+		return &FunctionLiteral{
+			Token:      node.Token,
+			Parameters: copyIdentifiers(node.Parameters),
+			Body:       copyBlock(node.Body),
+			Name:       node.Name,
+		}
+
+	case *MacroLiteral:
+		return &MacroLiteral{Token: node.Token, Parameters: copyIdentifiers(node.Parameters), Body: copyBlock(node.Body)}
+
+	case *CallExpression:
+		return &CallExpression{Token: node.Token, Function: copyExpression(node.Function), Arguments: copyExpressions(node.Arguments)}
+
+	case *ArrayLiteral:
+		return &ArrayLiteral{Token: node.Token, Elements: copyExpressions(node.Elements)}
+
+	case *HashLiteral:
+		pairs := make(map[Expression]Expression, len(node.Pairs))
+		for key, value := range node.Pairs {
+			pairs[copyExpression(key)] = copyExpression(value)
+		}
+		return &HashLiteral{Token: node.Token, Pairs: pairs}
+	}
+
+	return node
+}
+
+func copyStatement(statement Statement) Statement {
+	if statement == nil {
+		return nil
+	}
+
+	copied, _ := Copy(statement).(Statement)
+	return copied
+}
+
+func copyExpression(expression Expression) Expression {
+	if expression == nil {
+		return nil
+	}
+
+	copied, _ := Copy(expression).(Expression)
+	return copied
+}
+
+func copyExpressions(expressions []Expression) []Expression {
+	if expressions == nil {
+		return nil
+	}
+
+	copied := make([]Expression, len(expressions))
+	for i, expression := range expressions {
+		copied[i] = copyExpression(expression)
+	}
+
+	return copied
+}
+
+func copyBlock(block *BlockStatement) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+
+	statements := make([]Statement, len(block.Statements))
+	for i, statement := range block.Statements {
+		statements[i] = copyStatement(statement)
+	}
+
+	return &BlockStatement{Token: block.Token, Statements: statements}
+}
+
+func copyIdentifier(identifier *Identifier) *Identifier {
+	if identifier == nil {
+		return nil
+	}
+
+	copied := *identifier
+	return &copied
+}
+
+func copyIdentifiers(identifiers []*Identifier) []*Identifier {
+	if identifiers == nil {
+		return nil
+	}
+
+	copied := make([]*Identifier, len(identifiers))
+	for i, identifier := range identifiers {
+		copied[i] = copyIdentifier(identifier)
+	}
+
+	return copied
+}
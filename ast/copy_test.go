@@ -0,0 +1,31 @@
+package ast_test
+
+import (
+	"monkey/ast"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopy(t *testing.T) {
+	original := &ast.ArrayLiteral{
+		Elements: []ast.Expression{
+			&ast.IntegerLiteral{Value: 1},
+			&ast.InfixExpression{Left: &ast.IntegerLiteral{Value: 1}, Operator: "+", Right: &ast.IntegerLiteral{Value: 1}},
+		},
+	}
+
+	copied := ast.Copy(original)
+	assert.Equal(t, original, copied)
+	assert.NotSame(t, original, copied)
+
+	ast.Modify(copied, func(node ast.Node) ast.Node {
+		if integer, ok := node.(*ast.IntegerLiteral); ok {
+			integer.Value = 2
+		}
+		return node
+	})
+
+	assert.Equal(t, int64(1), original.Elements[0].(*ast.IntegerLiteral).Value)
+	assert.Equal(t, int64(1), original.Elements[1].(*ast.InfixExpression).Right.(*ast.IntegerLiteral).Value)
+}
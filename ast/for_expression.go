@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"bytes"
+	"monkey/token"
+)
+
+// ForExpression runs Body once for every value of Iterable, with Variable
+// bound to the value. Like a `let`, the variable is defined in the enclosing
+// scope.
+type ForExpression struct {
+	Token    token.Token // The `token.FOR` token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fe *ForExpression) expressionNode() {
+
+}
+
+func (fe *ForExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+
+func (fe *ForExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	out.WriteString(fe.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fe.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fe.Body.String())
+
+	return out.String()
+}
@@ -1,6 +1,9 @@
 package ast
 
-import "monkey/token"
+import (
+	"math/big"
+	"monkey/token"
+)
 
 type IntegerLiteral struct {
 	Token token.Token
@@ -18,3 +21,21 @@ func (il *IntegerLiteral) TokenLiteral() string {
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
+
+// BigIntegerLiteral is an integer literal too large for an int64.
+type BigIntegerLiteral struct {
+	Token token.Token
+	Value *big.Int
+}
+
+func (bl *BigIntegerLiteral) expressionNode() {
+
+}
+
+func (bl *BigIntegerLiteral) TokenLiteral() string {
+	return bl.Token.Literal
+}
+
+func (bl *BigIntegerLiteral) String() string {
+	return bl.Token.Literal
+}
@@ -84,6 +84,23 @@ func TestModify(t *testing.T) {
 				},
 			},
 		},
+		{
+			"ForExpression",
+			&ast.ForExpression{
+				Variable: &ast.Identifier{Value: "x"},
+				Iterable: one(),
+				Body: &ast.BlockStatement{
+					Statements: []ast.Statement{&ast.ExpressionStatement{Expression: one()}},
+				},
+			},
+			&ast.ForExpression{
+				Variable: &ast.Identifier{Value: "x"},
+				Iterable: two(),
+				Body: &ast.BlockStatement{
+					Statements: []ast.Statement{&ast.ExpressionStatement{Expression: two()}},
+				},
+			},
+		},
 		{
 			"ReturnStatement",
 			&ast.ReturnStatement{ReturnValue: one()},
@@ -118,6 +135,11 @@ func TestModify(t *testing.T) {
 			&ast.ArrayLiteral{Elements: []ast.Expression{one(), two()}},
 			&ast.ArrayLiteral{Elements: []ast.Expression{two(), two()}},
 		},
+		{
+			"CallExpression",
+			&ast.CallExpression{Function: &ast.Identifier{Value: "add"}, Arguments: []ast.Expression{one(), two()}},
+			&ast.CallExpression{Function: &ast.Identifier{Value: "add"}, Arguments: []ast.Expression{two(), two()}},
+		},
 	}
 
 	for _, tC := range testCases {
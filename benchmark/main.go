@@ -37,11 +37,15 @@ func main() {
 
 	l := lexer.New(input)
 	p := parser.New(l)
-	program := p.ParseProgram()
+	program, err := evaluator.ProcessMacros(object.NewEnvironment(), p.ParseProgram())
+	if err != nil {
+		fmt.Printf("macro error: %s", err)
+		return
+	}
 
 	if *engine == "vm" {
 		comp := compiler.New()
-		err := comp.Compile(program)
+		err = comp.Compile(program)
 		if err != nil {
 			fmt.Printf("compiler error: %s", err)
 			return
@@ -38,6 +38,9 @@ const (
 	OpReturnValue
 	OpClosure
 	OpCurrentClosure
+	OpTailCall
+	OpIterator
+	OpIterNext
 )
 
 type Definition struct {
@@ -76,6 +79,9 @@ var definitions = map[Opcode]*Definition{
 	OpReturnValue:    {"OpReturnValue", []int{}},
 	OpClosure:        {"OpClosure", []int{2, 1}},
 	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+	OpTailCall:       {"OpTailCall", []int{1}},
+	OpIterator:       {"OpIterator", []int{}},
+	OpIterNext:       {"OpIterNext", []int{2}},
 }
 
 func Lookup(op byte) (*Definition, error) {
@@ -18,6 +18,7 @@ func TestMake(t *testing.T) {
 		{code.OpAdd, []int{}, []byte{byte(code.OpAdd)}},
 		{code.OpGetLocal, []int{255}, []byte{byte(code.OpGetLocal), 255}},
 		{code.OpClosure, []int{65534, 255}, []byte{byte(code.OpClosure), 255, 254, 255}},
+		{code.OpIterNext, []int{65534}, []byte{byte(code.OpIterNext), 255, 254}},
 	}
 
 	for _, tt := range tests {
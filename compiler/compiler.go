@@ -5,6 +5,7 @@ import (
 	"monkey/ast"
 	"monkey/code"
 	"monkey/object"
+	"monkey/token"
 	"sort"
 )
 
@@ -17,6 +18,7 @@ type CompilationScope struct {
 	instructions        code.Instructions
 	lastInstruction     EmittedInstruction
 	previousInstruction EmittedInstruction
+	positions           map[int]token.Position
 }
 
 type Compiler struct {
@@ -34,6 +36,7 @@ func New() *Compiler {
 		instructions:        code.Instructions{},
 		lastInstruction:     EmittedInstruction{},
 		previousInstruction: EmittedInstruction{},
+		positions:           map[int]token.Position{},
 	}
 
 	symbolTable := NewSymbolTable()
@@ -60,11 +63,17 @@ func NewWithState(symbols *SymbolTable, constants []object.Object) *Compiler {
 }
 
 func (c *Compiler) Compile(node ast.Node) error {
+	return c.compile(node, inExpression)
+}
+
+// compile compiles node, which is at position pos of the function body being
+// compiled.
+func (c *Compiler) compile(node ast.Node, pos position) error {
 	switch node := node.(type) {
 
 	case *ast.ArrayLiteral:
 		for _, el := range node.Elements {
-			err := c.Compile(el)
+			err := c.compile(el, inExpression)
 			if err != nil {
 				return err
 			}
@@ -72,8 +81,8 @@ func (c *Compiler) Compile(node ast.Node) error {
 		c.emit(code.OpArray, len(node.Elements))
 
 	case *ast.BlockStatement:
-		for _, s := range node.Statements {
-			err := c.Compile(s)
+		for i, s := range node.Statements {
+			err := c.compile(s, pos.statement(i == len(node.Statements)-1))
 			if err != nil {
 				return err
 			}
@@ -87,27 +96,76 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 
 	case *ast.CallExpression:
-		err := c.Compile(node.Function)
+		if node.Function.TokenLiteral() == "macroexpand" {
+			if len(node.Arguments) != 1 {
+				return fmt.Errorf("wrong number of arguments to macroexpand: want=1, got=%d", len(node.Arguments))
+			}
+			// Macro calls in the argument have already been expanded by the
+			// front end, so the argument itself is the expanded quote
+			quote := &object.Quote{Node: node.Arguments[0]}
+			c.emit(code.OpConstant, c.addConstant(quote))
+			return nil
+		}
+
+		err := c.compile(node.Function, inExpression)
 		if err != nil {
 			return err
 		}
 
 		for _, arg := range node.Arguments {
-			err := c.Compile(arg)
+			err := c.compile(arg, inExpression)
 			if err != nil {
-				return nil
+				return err
 			}
 		}
 
-		c.emit(code.OpCall, len(node.Arguments))
+		var offset int
+		if pos == inTail {
+			offset = c.emit(code.OpTailCall, len(node.Arguments))
+		} else {
+			offset = c.emit(code.OpCall, len(node.Arguments))
+		}
+		c.currentScope().positions[offset] = callSitePosition(node)
 
 	case *ast.ExpressionStatement:
-		err := c.Compile(node.Expression)
+		err := c.compile(node.Expression, pos)
 		if err != nil {
 			return err
 		}
 		c.emit(code.OpPop)
 
+	case *ast.ForExpression:
+		err := c.compile(node.Iterable, inExpression)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpIterator)
+
+		loopStart := len(c.currentInstructions())
+		// Emit an `OpIterNext` with a bogus value
+		iterNextPos := c.emit(code.OpIterNext, 9999)
+		symbol := c.symbolTable.Redefine(node.Variable.Value)
+		c.storeSymbol(symbol)
+
+		// Locals are copied into closures when they are created, so every
+		// iteration gets its own binding. Globals are shared, so closures
+		// have to capture the loop variable like a local to get the same.
+		if symbol.Scope == GlobalScope {
+			c.symbolTable.BeginLoop(symbol.Name)
+		}
+		err = c.compile(node.Body, inExpression)
+		if symbol.Scope == GlobalScope {
+			c.symbolTable.EndLoop(symbol.Name)
+		}
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpJump, loopStart)
+
+		afterLoopPos := len(c.currentInstructions())
+		c.changeOperand(iterNextPos, afterLoopPos)
+		c.emit(code.OpNull)
+
 	case *ast.FunctionLiteral:
 		c.enterScope()
 
@@ -119,7 +177,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 			c.symbolTable.Define(param.Value)
 		}
 
-		err := c.Compile(node.Body)
+		err := c.compile(node.Body, inTail)
 		if err != nil {
 			return err
 		}
@@ -133,6 +191,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 		freeSymbols := c.symbolTable.FreeSymbols
 		numLocals := c.symbolTable.numDefinitions
+		positions := c.currentScope().positions
 		instructions := c.leaveScope()
 
 		for _, s := range freeSymbols {
@@ -143,6 +202,8 @@ func (c *Compiler) Compile(node ast.Node) error {
 			Instructions:  instructions,
 			NumLocals:     numLocals,
 			NumParameters: len(node.Parameters),
+			Name:          node.Name,
+			Positions:     positions,
 		}
 		fnIndex := c.addConstant(compiledFn)
 		c.emit(code.OpClosure, fnIndex, len(freeSymbols))
@@ -157,11 +218,11 @@ func (c *Compiler) Compile(node ast.Node) error {
 		})
 
 		for _, k := range keys {
-			err := c.Compile(k)
+			err := c.compile(k, inExpression)
 			if err != nil {
 				return err
 			}
-			err = c.Compile(node.Pairs[k])
+			err = c.compile(node.Pairs[k], inExpression)
 			if err != nil {
 				return err
 			}
@@ -178,14 +239,14 @@ func (c *Compiler) Compile(node ast.Node) error {
 		c.loadSymbols(symbol)
 
 	case *ast.IfExpression:
-		err := c.Compile(node.Condition)
+		err := c.compile(node.Condition, inExpression)
 		if err != nil {
 			return err
 		}
 
 		// Emit an `OpJumpNotTruthy` with a bogus value
 		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
-		err = c.Compile(node.Consequence)
+		err = c.compile(node.Consequence, pos)
 		if err != nil {
 			return err
 		}
@@ -203,7 +264,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 		if node.Alternative == nil {
 			c.emit(code.OpNull)
 		} else {
-			err := c.Compile(node.Alternative)
+			err := c.compile(node.Alternative, pos)
 			if err != nil {
 				return err
 			}
@@ -217,11 +278,11 @@ func (c *Compiler) Compile(node ast.Node) error {
 		c.changeOperand(jumpPos, afterAlternativePos)
 
 	case *ast.IndexExpression:
-		err := c.Compile(node.Left)
+		err := c.compile(node.Left, inExpression)
 		if err != nil {
 			return err
 		}
-		err = c.Compile(node.Index)
+		err = c.compile(node.Index, inExpression)
 		if err != nil {
 			return err
 		}
@@ -229,12 +290,12 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 	case *ast.InfixExpression:
 		if node.Operator == "<" {
-			err := c.Compile(node.Right)
+			err := c.compile(node.Right, inExpression)
 			if err != nil {
 				return err
 			}
 
-			err = c.Compile(node.Left)
+			err = c.compile(node.Left, inExpression)
 			if err != nil {
 				return err
 			}
@@ -243,12 +304,12 @@ func (c *Compiler) Compile(node ast.Node) error {
 			return nil
 		}
 
-		err := c.Compile(node.Left)
+		err := c.compile(node.Left, inExpression)
 		if err != nil {
 			return err
 		}
 
-		err = c.Compile(node.Right)
+		err = c.compile(node.Right, inExpression)
 		if err != nil {
 			return err
 		}
@@ -276,20 +337,22 @@ func (c *Compiler) Compile(node ast.Node) error {
 		integer := &object.Integer{Value: node.Value}
 		c.emit(code.OpConstant, c.addConstant(integer))
 
+	case *ast.BigIntegerLiteral:
+		integer := &object.BigInt{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(integer))
+
 	case *ast.LetStatement:
-		symbol := c.symbolTable.Define(node.Name.Value)
-		err := c.Compile(node.Value)
+		// The value is compiled first, so `let x = x + 1` refers to the x
+		// defined before. Functions refer to themselves with OpCurrentClosure.
+		err := c.compile(node.Value, inExpression)
 		if err != nil {
 			return err
 		}
-		if symbol.Scope == GlobalScope {
-			c.emit(code.OpSetGlobal, symbol.Index)
-		} else {
-			c.emit(code.OpSetLocal, symbol.Index)
-		}
+		symbol := c.symbolTable.Redefine(node.Name.Value)
+		c.storeSymbol(symbol)
 
 	case *ast.PrefixExpression:
-		err := c.Compile(node.Right)
+		err := c.compile(node.Right, inExpression)
 		if err != nil {
 			return err
 		}
@@ -303,16 +366,19 @@ func (c *Compiler) Compile(node ast.Node) error {
 			return fmt.Errorf("unknown operator %s", node.Operator)
 		}
 
+	case *ast.MacroLiteral:
+		return fmt.Errorf("macros can only be defined at the top level")
+
 	case *ast.Program:
 		for _, s := range node.Statements {
-			err := c.Compile(s)
+			err := c.compile(s, inExpression)
 			if err != nil {
-				return nil
+				return err
 			}
 		}
 
 	case *ast.ReturnStatement:
-		err := c.Compile(node.ReturnValue)
+		err := c.compile(node.ReturnValue, pos.returned())
 		if err != nil {
 			return err
 		}
@@ -330,12 +396,14 @@ func (c *Compiler) Bytecode() *Bytecode {
 	return &Bytecode{
 		Instructions: c.currentInstructions(),
 		Constants:    c.constants,
+		Positions:    c.currentScope().positions,
 	}
 }
 
 type Bytecode struct {
 	Instructions code.Instructions
 	Constants    []object.Object
+	Positions    map[int]token.Position // Source positions of the calls, by instruction offset
 }
 
 func (c *Compiler) addConstant(obj object.Object) int {
@@ -420,6 +488,7 @@ func (c *Compiler) enterScope() {
 		instructions:        code.Instructions{},
 		lastInstruction:     EmittedInstruction{},
 		previousInstruction: EmittedInstruction{},
+		positions:           map[int]token.Position{},
 	}
 
 	c.scopes = append(c.scopes, scope)
@@ -436,6 +505,24 @@ func (c *Compiler) leaveScope() code.Instructions {
 	return instructions
 }
 
+// callSitePosition is the position reported for a call in tracebacks: the
+// name of the function if it is called by name, the `(` otherwise.
+func callSitePosition(call *ast.CallExpression) token.Position {
+	if identifier, ok := call.Function.(*ast.Identifier); ok {
+		return identifier.Token.Pos
+	}
+
+	return call.Token.Pos
+}
+
+func (c *Compiler) storeSymbol(symbol Symbol) {
+	if symbol.Scope == GlobalScope {
+		c.emit(code.OpSetGlobal, symbol.Index)
+	} else {
+		c.emit(code.OpSetLocal, symbol.Index)
+	}
+}
+
 func (c *Compiler) loadSymbols(symbol Symbol) {
 	switch symbol.Scope {
 	case GlobalScope:
@@ -1,9 +1,14 @@
 package compiler_test
 
 import (
+	"monkey/ast"
 	"monkey/code"
+	"monkey/compiler"
 	"monkey/testutil"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type compilerTestCase struct {
@@ -295,6 +300,94 @@ func TestGlobalLetStatements(t *testing.T) {
 	runCompilerTests(t, tests)
 }
 
+func TestLetRedefinition(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `
+			let one = 1;
+			let one = one + 1;
+			`,
+			expectedConstants: []any{1, 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestForExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "for (x in [1]) { x }; 2;",
+			expectedConstants: []any{1, 2},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpConstant, 0),
+				// 0003
+				code.Make(code.OpArray, 1),
+				// 0006
+				code.Make(code.OpIterator),
+				// 0007
+				code.Make(code.OpIterNext, 20),
+				// 0010
+				code.Make(code.OpSetGlobal, 0),
+				// 0013
+				code.Make(code.OpGetGlobal, 0),
+				// 0016
+				code.Make(code.OpPop),
+				// 0017
+				code.Make(code.OpJump, 7),
+				// 0020
+				code.Make(code.OpNull),
+				// 0021
+				code.Make(code.OpPop),
+				// 0022
+				code.Make(code.OpConstant, 1),
+				// 0025
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "fn(xs) { for (x in xs) { x } }",
+			expectedConstants: []any{
+				[]code.Instructions{
+					// 0000
+					code.Make(code.OpGetLocal, 0),
+					// 0002
+					code.Make(code.OpIterator),
+					// 0003
+					code.Make(code.OpIterNext, 14),
+					// 0006
+					code.Make(code.OpSetLocal, 1),
+					// 0008
+					code.Make(code.OpGetLocal, 1),
+					// 0010
+					code.Make(code.OpPop),
+					// 0011
+					code.Make(code.OpJump, 3),
+					// 0014
+					code.Make(code.OpNull),
+					// 0015
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
 func TestArrayLiterals(t *testing.T) {
 	tests := []compilerTestCase{
 		{
@@ -665,7 +758,7 @@ func TestBuiltins(t *testing.T) {
 				[]code.Instructions{
 					code.Make(code.OpGetBuiltin, 0),
 					code.Make(code.OpArray, 0),
-					code.Make(code.OpCall, 1),
+					code.Make(code.OpTailCall, 1),
 					code.Make(code.OpReturnValue),
 				},
 			},
@@ -800,7 +893,7 @@ countDown(1);`,
 					code.Make(code.OpGetLocal, 0),
 					code.Make(code.OpConstant, 0),
 					code.Make(code.OpSub),
-					code.Make(code.OpCall, 1),
+					code.Make(code.OpTailCall, 1),
 					code.Make(code.OpReturnValue),
 				},
 				1,
@@ -828,7 +921,7 @@ wrapper();`,
 					code.Make(code.OpGetLocal, 0),
 					code.Make(code.OpConstant, 0),
 					code.Make(code.OpSub),
-					code.Make(code.OpCall, 1),
+					code.Make(code.OpTailCall, 1),
 					code.Make(code.OpReturnValue),
 				},
 				1,
@@ -837,7 +930,7 @@ wrapper();`,
 					code.Make(code.OpSetLocal, 0),
 					code.Make(code.OpGetLocal, 0),
 					code.Make(code.OpConstant, 2),
-					code.Make(code.OpCall, 1),
+					code.Make(code.OpTailCall, 1),
 					code.Make(code.OpReturnValue),
 				},
 			},
@@ -854,6 +947,109 @@ wrapper();`,
 	runCompilerTests(t, tests)
 }
 
+func TestTailCalls(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `
+let f = fn(x) {
+	if (x) { return f(x); }
+	let y = f(x);
+	if (x) { f(x) } else { f(y) }
+};`,
+			expectedConstants: []any{
+				[]code.Instructions{
+					// 0000
+					code.Make(code.OpGetLocal, 0),
+					// 0002
+					code.Make(code.OpJumpNotTruthy, 14),
+					// 0005
+					code.Make(code.OpCurrentClosure),
+					// 0006
+					code.Make(code.OpGetLocal, 0),
+					// 0008
+					code.Make(code.OpTailCall, 1),
+					// 0010
+					code.Make(code.OpReturnValue),
+					// 0011
+					code.Make(code.OpJump, 15),
+					// 0014
+					code.Make(code.OpNull),
+					// 0015
+					code.Make(code.OpPop),
+					// 0016
+					code.Make(code.OpCurrentClosure),
+					// 0017
+					code.Make(code.OpGetLocal, 0),
+					// 0019
+					code.Make(code.OpCall, 1),
+					// 0021
+					code.Make(code.OpSetLocal, 1),
+					// 0023
+					code.Make(code.OpGetLocal, 0),
+					// 0025
+					code.Make(code.OpJumpNotTruthy, 36),
+					// 0028
+					code.Make(code.OpCurrentClosure),
+					// 0029
+					code.Make(code.OpGetLocal, 0),
+					// 0031
+					code.Make(code.OpTailCall, 1),
+					// 0033
+					code.Make(code.OpJump, 41),
+					// 0036
+					code.Make(code.OpCurrentClosure),
+					// 0037
+					code.Make(code.OpGetLocal, 1),
+					// 0039
+					code.Make(code.OpTailCall, 1),
+					// 0041
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestNestedMacroDefinition(t *testing.T) {
+	program := testutil.SetupProgram(t, `fn() { let m = macro(x) { x }; }`, 1)
+	comp := compiler.New()
+	err := comp.Compile(program)
+	assert.EqualError(t, err, "macros can only be defined at the top level")
+}
+
+func TestTailCallsWithSharedNodes(t *testing.T) {
+	// Macros can put the same node in several places, only the copy in tail
+	// position may become a tail call
+	program := testutil.SetupProgram(t, "fn(x) { let a = x(1); a }", 1)
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	call := fn.Body.Statements[0].(*ast.LetStatement).Value
+	fn.Body.Statements[1] = &ast.ExpressionStatement{Expression: call}
+
+	comp := compiler.New()
+	require.NoError(t, comp.Compile(program))
+
+	testutil.AssertConstants(t, comp.Bytecode().Constants, []any{
+		1,
+		1,
+		[]code.Instructions{
+			code.Make(code.OpGetLocal, 0),
+			code.Make(code.OpConstant, 0),
+			code.Make(code.OpCall, 1),
+			code.Make(code.OpSetLocal, 1),
+			code.Make(code.OpGetLocal, 0),
+			code.Make(code.OpConstant, 1),
+			code.Make(code.OpTailCall, 1),
+			code.Make(code.OpReturnValue),
+		},
+	})
+}
+
 func runCompilerTests(t *testing.T, tests []compilerTestCase) {
 	t.Helper()
 
@@ -22,6 +22,7 @@ type SymbolTable struct {
 
 	store          map[string]Symbol
 	numDefinitions int
+	loopVariables  map[string]int
 }
 
 func NewSymbolTable() *SymbolTable {
@@ -49,6 +50,16 @@ func (s *SymbolTable) Define(name string) Symbol {
 	return symbol
 }
 
+// Redefine returns the symbol name is already defined as in this table, so
+// defining it again reuses its slot. Otherwise it defines a new symbol.
+func (s *SymbolTable) Redefine(name string) Symbol {
+	if symbol, ok := s.store[name]; ok && (symbol.Scope == GlobalScope || symbol.Scope == LocalScope) {
+		return symbol
+	}
+
+	return s.Define(name)
+}
+
 func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
 	symbol := Symbol{Name: name, Index: index, Scope: BuiltinScope}
 	s.store[name] = symbol
@@ -69,6 +80,31 @@ func (s *SymbolTable) defineFree(original Symbol) Symbol {
 	return symbol
 }
 
+// BeginLoop marks the global name as the variable of a loop that is being
+// compiled. Functions in the loop capture it like a local, so each of them
+// keeps the value of the iteration it was created in.
+func (s *SymbolTable) BeginLoop(name string) {
+	if s.loopVariables == nil {
+		s.loopVariables = make(map[string]int)
+	}
+	s.loopVariables[name]++
+}
+
+// EndLoop undoes BeginLoop once the loop has been compiled.
+func (s *SymbolTable) EndLoop(name string) {
+	s.loopVariables[name]--
+}
+
+// isLoopVariable reports whether name is the variable of a loop over globals
+// that is being compiled.
+func (s *SymbolTable) isLoopVariable(name string) bool {
+	for s.Outer != nil {
+		s = s.Outer
+	}
+
+	return s.loopVariables[name] > 0
+}
+
 func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
 	obj, ok := s.store[name]
 	if !ok && s.Outer != nil {
@@ -77,7 +113,7 @@ func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
 			return obj, ok
 		}
 
-		if obj.Scope == GlobalScope || obj.Scope == BuiltinScope {
+		if obj.Scope == BuiltinScope || (obj.Scope == GlobalScope && !s.isLoopVariable(name)) {
 			return obj, ok
 		}
 
@@ -258,3 +258,23 @@ func TestShadowingFunctionName(t *testing.T) {
 	require.True(t, ok)
 	assert.EqualValues(t, expected, result)
 }
+
+func TestResolveLoopVariable(t *testing.T) {
+	global := compiler.NewSymbolTable()
+	global.Define("a")
+	global.Define("i")
+
+	global.BeginLoop("i")
+	local := compiler.NewEnclosedSymbolTable(global)
+
+	a, _ := local.Resolve("a")
+	assert.Equal(t, compiler.Symbol{Name: "a", Scope: compiler.GlobalScope, Index: 0}, a)
+	i, _ := local.Resolve("i")
+	assert.Equal(t, compiler.Symbol{Name: "i", Scope: compiler.FreeScope, Index: 0}, i)
+	assert.Equal(t, []compiler.Symbol{{Name: "i", Scope: compiler.GlobalScope, Index: 1}}, local.FreeSymbols)
+
+	global.EndLoop("i")
+	after := compiler.NewEnclosedSymbolTable(global)
+	i, _ = after.Resolve("i")
+	assert.Equal(t, compiler.Symbol{Name: "i", Scope: compiler.GlobalScope, Index: 1}, i)
+}
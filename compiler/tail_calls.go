@@ -0,0 +1,36 @@
+package compiler
+
+// position tells where a node is in the function body being compiled. Calls
+// in tail position, which are the operand of a `return` and the last
+// expression of the body, including through the branches of an `if`, are
+// compiled to OpTailCall.
+type position int
+
+const (
+	// inExpression is anywhere tail calls aren't looked for, like the top
+	// level or the value of a `let`.
+	inExpression position = iota
+	// inBody is a statement of the body, or of an `if` in it, whose value
+	// isn't returned.
+	inBody
+	// inTail is an expression whose value is returned from the function.
+	inTail
+)
+
+// statement returns the position of a statement of a block at pos.
+func (pos position) statement(isLast bool) position {
+	if pos == inExpression || isLast {
+		return pos
+	}
+
+	return inBody
+}
+
+// returned returns the position of the operand of a `return` at pos.
+func (pos position) returned() position {
+	if pos == inExpression {
+		return inExpression
+	}
+
+	return inTail
+}
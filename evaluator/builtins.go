@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"monkey/ast"
 	"monkey/object"
 )
 
@@ -11,4 +12,83 @@ var builtins = map[string]*object.Builtin{
 	"rest":  object.GetBuiltinByName("rest"),
 	"push":  object.GetBuiltinByName("push"),
 	"puts":  object.GetBuiltinByName("puts"),
+	"set":   object.GetBuiltinByName("set"),
+
+	"error":         object.GetBuiltinByName("error"),
+	"is_error":      object.GetBuiltinByName("is_error"),
+	"error_kind":    object.GetBuiltinByName("error_kind"),
+	"error_message": object.GetBuiltinByName("error_message"),
+
+	"map":     object.GetBuiltinByName("map"),
+	"filter":  object.GetBuiltinByName("filter"),
+	"reduce":  object.GetBuiltinByName("reduce"),
+	"each":    object.GetBuiltinByName("each"),
+	"sort_by": object.GetBuiltinByName("sort_by"),
+
+	"split":       object.GetBuiltinByName("split"),
+	"join":        object.GetBuiltinByName("join"),
+	"trim":        object.GetBuiltinByName("trim"),
+	"upper":       object.GetBuiltinByName("upper"),
+	"lower":       object.GetBuiltinByName("lower"),
+	"contains":    object.GetBuiltinByName("contains"),
+	"starts_with": object.GetBuiltinByName("starts_with"),
+	"ends_with":   object.GetBuiltinByName("ends_with"),
+	"replace":     object.GetBuiltinByName("replace"),
+	"index_of":    object.GetBuiltinByName("index_of"),
+	"substr":      object.GetBuiltinByName("substr"),
+	"repeat":      object.GetBuiltinByName("repeat"),
+	"chars":       object.GetBuiltinByName("chars"),
+
+	"keys":    object.GetBuiltinByName("keys"),
+	"values":  object.GetBuiltinByName("values"),
+	"entries": object.GetBuiltinByName("entries"),
+	"has":     object.GetBuiltinByName("has"),
+	"get":     object.GetBuiltinByName("get"),
+	"delete":  object.GetBuiltinByName("delete"),
+	"merge":   object.GetBuiltinByName("merge"),
+
+	"type": object.GetBuiltinByName("type"),
+	"int":  object.GetBuiltinByName("int"),
+	"str":  object.GetBuiltinByName("str"),
+	"bool": object.GetBuiltinByName("bool"),
+
+	"json_parse":     object.GetBuiltinByName("json_parse"),
+	"json_stringify": object.GetBuiltinByName("json_stringify"),
+
+	"print":     object.GetBuiltinByName("print"),
+	"eprint":    object.GetBuiltinByName("eprint"),
+	"read_line": object.GetBuiltinByName("read_line"),
+
+	"read_file":  object.GetBuiltinByName("read_file"),
+	"write_file": object.GetBuiltinByName("write_file"),
+	"list_dir":   object.GetBuiltinByName("list_dir"),
+	"exists":     object.GetBuiltinByName("exists"),
+	"remove":     object.GetBuiltinByName("remove"),
+
+	"now":        object.GetBuiltinByName("now"),
+	"sleep":      object.GetBuiltinByName("sleep"),
+	"random_int": object.GetBuiltinByName("random_int"),
+	"shuffle":    object.GetBuiltinByName("shuffle"),
+
+	"range": object.GetBuiltinByName("range"),
+
+	"re_match":    object.GetBuiltinByName("re_match"),
+	"re_find_all": object.GetBuiltinByName("re_find_all"),
+	"re_replace":  object.GetBuiltinByName("re_replace"),
+	"re_split":    object.GetBuiltinByName("re_split"),
+}
+
+// engine lets builtins call back into the evaluator. Functions they call
+// show up in tracebacks as called from the builtin's call site.
+type engine struct {
+	runtime *object.Runtime
+	call    *ast.CallExpression
+}
+
+func (e *engine) Call(fn object.Object, args ...object.Object) object.Object {
+	return applyFunction(e.runtime, fn, args, e.call)
+}
+
+func (e *engine) Runtime() *object.Runtime {
+	return e.runtime
 }
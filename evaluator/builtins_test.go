@@ -1,9 +1,14 @@
 package evaluator_test
 
 import (
+	"bytes"
+	"fmt"
+	"monkey/evaluator"
 	"monkey/object"
 	"monkey/testutil"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,4 +39,351 @@ func TestBuiltinFunctions(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("set", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`set({}, 1, 2)[1]`, 2},
+			{`set({1: 2}, 1, 3)[1]`, 3},
+			{`let a = {1: 2}; let b = set(a, 3, 4); a[3]`, nil},
+			{`let a = {1: 2}; let b = set(a, 3, 4); len([a, b]) + b[1] + b[3]`, 8},
+			{`set(1, 1, 1)`, &object.Error{Message: "argument to `set` must be a HASH, got INTEGER"}},
+			{`set({}, fn() {}, 1)`, &object.Error{Message: "unusable as hash key: FUNCTION"}},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`is_error(error("ValueError", "bad input"))`, true},
+			{`is_error(1)`, false},
+			{`is_error(len(1))`, true},
+			{`let e = error("ValueError", "bad input"); error_kind(e)`, "ValueError"},
+			{`let e = error("ValueError", "bad input"); error_message(e)`, "bad input"},
+			{`error_kind(len(1))`, "TypeError"},
+			{`error_kind(first())`, "ArgumentError"},
+			{`let check = fn(x) { if (x < 0) { error("RangeError", "negative") } else { x } }; let r = check(-1); if (is_error(r)) { error_message(r) } else { r }`, "negative"},
+			{`error_message(error("Outer", "failed", error("Inner", "cause")))`, "failed"},
+			{`error(1, "a")`, &object.Error{Message: "argument to `error` must be a STRING, got INTEGER"}},
+			{`error("a", "b", 1)`, &object.Error{Message: "cause passed to `error` must be an ERROR, got INTEGER"}},
+			{`error("a")`, &object.Error{Message: "wrong number of arguments. got=1, want=2 or 3"}},
+			{`error_kind(1)`, &object.Error{Message: "argument to `error_kind` must be an ERROR, got INTEGER"}},
+			{`error_message(1)`, &object.Error{Message: "argument to `error_message` must be an ERROR, got INTEGER"}},
+			{`let e = error("ValueError", "bad input"); e + 1`, &object.Error{Message: "bad input"}},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+	})
+
+	t.Run("strings", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`split("a,b,c", ",")`, []string{"a", "b", "c"}},
+			{`split("abc", "")`, []string{"a", "b", "c"}},
+			{`split("", ",")`, []string{""}},
+			{`join(["a", "b", "c"], "-")`, "a-b-c"},
+			{`join([], "-")`, ""},
+			{`join([1], "-")`, &object.Error{Message: "elements passed to `join` must be STRINGs, got INTEGER"}},
+			{`join("a", "-")`, &object.Error{Message: "argument to `join` must be an ARRAY, got STRING"}},
+			{`trim("  hi  ")`, "hi"},
+			{`upper("Hello")`, "HELLO"},
+			{`lower("Hello")`, "hello"},
+			{`upper(1)`, &object.Error{Message: "argument to `upper` must be a STRING, got INTEGER"}},
+			{`contains("monkey", "key")`, true},
+			{`contains("monkey", "donkey")`, false},
+			{`starts_with("monkey", "mon")`, true},
+			{`ends_with("monkey", "mon")`, false},
+			{`starts_with("monkey")`, &object.Error{Message: "wrong number of arguments. got=1, want=2"}},
+			{`replace("a-b-c", "-", "+")`, "a+b+c"},
+			{`index_of("monkey", "key")`, 3},
+			{`index_of("monkey", "x")`, -1},
+			{`substr("monkey", 3)`, "key"},
+			{`substr("monkey", 1, 3)`, "onk"},
+			{`substr("monkey", 4, 10)`, "ey"},
+			{`substr("monkey", 6)`, ""},
+			{`substr("monkey", 7)`, &object.Error{Message: "start index 7 out of range for string of length 6"}},
+			{`substr("monkey", 1, -1)`, &object.Error{Message: "length passed to `substr` must not be negative, got -1"}},
+			{`substr("monkey", "1")`, &object.Error{Message: "argument to `substr` must be an INTEGER, got STRING"}},
+			{`len("héllo")`, 6},
+			{`index_of("héllo", "l")`, 3},
+			{`substr("héllo", 1, 2)`, "é"},
+			{`substr("héllo", index_of("héllo", "llo"))`, "llo"},
+			{`substr("héllo", 2)`, &object.Error{Message: "start index 2 passed to `substr` is inside a character"}},
+			{`substr("héllo", 1, 1)`, &object.Error{Message: "length 1 passed to `substr` ends inside a character"}},
+			{`repeat("ab", 3)`, "ababab"},
+			{`repeat("ab", 0)`, ""},
+			{`repeat("ab", -1)`, &object.Error{Message: "count passed to `repeat` must not be negative, got -1"}},
+			{`repeat("ab", 9223372036854775807)`, &object.Error{Message: "result of `repeat` would be longer than 1073741824 bytes"}},
+			{`chars("héllo")`, []string{"h", "é", "l", "l", "o"}},
+			{`chars(1)`, &object.Error{Message: "argument to `chars` must be a STRING, got INTEGER"}},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+	})
+
+	t.Run("hashes", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`keys({"b": 1, "a": 2, "c": 4})`, []string{"a", "b", "c"}},
+			{`keys({3: 1, -1: 2, 10: 3})`, []int{-1, 3, 10}},
+			{`values({"b": 1, "a": 2, "c": 4})`, []int{2, 1, 4}},
+			{`map(entries({"b": 1, "a": 2}), fn(e) { e[0] })`, []string{"a", "b"}},
+			{`map(entries({"b": 1, "a": 2}), fn(e) { e[1] })`, []int{2, 1}},
+			{`keys({})`, []int{}},
+			{`keys(1)`, &object.Error{Message: "argument to `keys` must be a HASH, got INTEGER"}},
+			{`has({"a": 1}, "a")`, true},
+			{`has({"a": 1}, "b")`, false},
+			{`has({[1, 2]: 1}, [1, 2])`, true},
+			{`has({}, len)`, &object.Error{Message: "unusable as hash key: BUILTIN"}},
+			{`get({"a": 1}, "a", 0)`, 1},
+			{`get({"a": 1}, "b", 0)`, 0},
+			{`get({"a": 1}, "b")`, nil},
+			{`get({"a": 1})`, &object.Error{Message: "wrong number of arguments. got=1, want=2 or 3"}},
+			{`delete({"a": 1, "b": 2}, "a")`, map[object.HashKey]int64{(&object.String{Value: "b"}).HashKey(): 2}},
+			{`delete({"a": 1}, "b")`, map[object.HashKey]int64{(&object.String{Value: "a"}).HashKey(): 1}},
+			{`let h = {"a": 1}; let d = delete(h, "a"); [len(keys(h)), len(keys(d))]`, []int{1, 0}},
+			{`delete({}, len)`, &object.Error{Message: "unusable as hash key: BUILTIN"}},
+			{`merge({1: 1, 2: 2}, {2: 3, 4: 4})`, map[object.HashKey]int64{
+				(&object.Integer{Value: 1}).HashKey(): 1,
+				(&object.Integer{Value: 2}).HashKey(): 3,
+				(&object.Integer{Value: 4}).HashKey(): 4,
+			}},
+			{`let h = {"a": 1}; let m = merge(h, {"a": 2}); h["a"]`, 1},
+			{`merge({}, 1)`, &object.Error{Message: "argument to `merge` must be a HASH, got INTEGER"}},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+	})
+
+	t.Run("types", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`type(1)`, "INTEGER"},
+			{`type(92233720368547758070)`, "INTEGER"},
+			{`type("a")`, "STRING"},
+			{`type(true)`, "BOOLEAN"},
+			{`type(if (false) { 1 })`, "NULL"},
+			{`type([])`, "ARRAY"},
+			{`type({})`, "HASH"},
+			{`type(fn(x) { x })`, "FUNCTION"},
+			{`type(len)`, "BUILTIN"},
+			{`type(len(1))`, "ERROR"},
+			{`type()`, &object.Error{Message: "wrong number of arguments. got=0, want=1"}},
+			{`int("42")`, 42},
+			{`int(" -7 ")`, -7},
+			{`int("92233720368547758070")`, bigInt("92233720368547758070")},
+			{`int(5)`, 5},
+			{`int(true)`, 1},
+			{`int(false)`, 0},
+			{`int("4x")`, &object.Error{Message: "could not parse \"4x\" as an integer"}},
+			{`int([])`, &object.Error{Message: "argument to `int` not supported, got ARRAY"}},
+			{`str(42)`, "42"},
+			{`str("a")`, "a"},
+			{`str(true)`, "true"},
+			{`str([1, "a"])`, "[1, a]"},
+			{`str({"b": 2, "a": 1})`, "{a: 1, b: 2}"},
+			{`str(if (false) { 1 })`, "null"},
+			{`bool(1)`, true},
+			{`bool(0)`, true},
+			{`bool("")`, true},
+			{`bool(false)`, false},
+			{`bool(if (false) { 1 })`, false},
+			{`int(str(123)) == 123`, true},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`json_parse("[1, 2, 3]")`, []int{1, 2, 3}},
+			{`json_parse(" true ")`, true},
+			{`json_parse("null")`, nil},
+			{`json_parse("[1,")`, &object.Error{Message: "invalid JSON: unexpected EOF"}},
+			{`json_parse(1)`, &object.Error{Message: "argument to `json_parse` must be a STRING, got INTEGER"}},
+			{`json_parse(json_stringify({"a": [1, 2]}))["a"]`, []int{1, 2}},
+			{`json_stringify([1, true, if (false) { 1 }])`, "[1,true,null]"},
+			{`json_stringify(["a", "b"])`, `["a","b"]`},
+			{`json_stringify({"b": 1, "a": {"c": []}})`, `{"a":{"c":[]},"b":1}`},
+			{`json_stringify([1], 2)`, "[\n  1\n]"},
+			{`json_stringify({1: 2})`, &object.Error{Message: "JSON object keys must be STRINGs, got INTEGER"}},
+			{`json_stringify([fn(x) { x }])`, &object.Error{Message: "cannot convert FUNCTION to JSON"}},
+			{`json_stringify(len)`, &object.Error{Message: "cannot convert BUILTIN to JSON"}},
+			{`json_stringify([1], -1)`, &object.Error{Message: "indent passed to `json_stringify` must be between 0 and 16, got -1"}},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+	})
+
+	t.Run("regexp", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`re_match("2024-10-19", "(\d+)-(\d+)")[2]`, "10"},
+			{`re_match("on 2024-10", "(?P<year>\d+)-(?P<month>\d+)")["year"]`, "2024"},
+			{`re_match("abc", "\d")`, nil},
+			{`re_match("ab", "a(x)?b")[1]`, nil},
+			{`len(keys(re_match("ab", "a(x)?b")))`, 2},
+			{`re_find_all("a1 b22 c333", "\d+")`, []string{"1", "22", "333"}},
+			{`re_find_all("abc", "\d")`, []string{}},
+			{`map(re_find_all("a=1, b=2", "(\w)=(\d)"), fn(m) { m[1] })`, []string{"a", "b"}},
+			{`re_replace("2024-10-19", "(\d+)-(\d+)-(\d+)", "$3/$2/$1")`, "19/10/2024"},
+			{`re_replace("a  b   c", " +", " ")`, "a b c"},
+			{`re_split("a, b;c", "[,;] *")`, []string{"a", "b", "c"}},
+			{`re_match("a", "(")`, &object.Error{Message: "invalid pattern passed to `re_match`: error parsing regexp: missing closing ): `(`"}},
+			{`re_split(1, "a")`, &object.Error{Message: "argument to `re_split` must be a STRING, got INTEGER"}},
+			{`re_replace("a", "a")`, &object.Error{Message: "wrong number of arguments. got=2, want=3"}},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+	})
+
+	t.Run("io", func(t *testing.T) {
+		input := `puts(1, "a");
+print("b", 2);
+eprint("oops");
+let first = read_line();
+print(first);
+read_line();
+read_line();`
+
+		var stdout, stderr bytes.Buffer
+		env := object.NewEnvironment()
+		env.Runtime().SetIO(object.IO{Stdout: &stdout, Stderr: &stderr, Stdin: strings.NewReader("x\ny\n")})
+		evaluated := evaluator.Eval(env, testutil.SetupProgram(t, input, 0))
+
+		assert.Equal(t, "1\na\nb2x", stdout.String())
+		assert.Equal(t, "oops\n", stderr.String())
+		testutil.AssertNullObject(t, evaluated)
+	})
+
+	t.Run("files", func(t *testing.T) {
+		dir := t.TempDir()
+		input := fmt.Sprintf(`let dir = "%s";
+write_file(dir + "/b.txt", "hello");
+write_file(dir + "/a.txt", upper(read_file(dir + "/b.txt")));
+let listed = list_dir(dir);
+remove(dir + "/b.txt");
+[listed, list_dir(dir), exists(dir + "/b.txt"), read_file(dir + "/a.txt"), is_error(read_file(dir + "/b.txt"))]`, dir)
+
+		env := object.NewEnvironment()
+		env.Runtime().SetFilePolicy(object.FilePolicy{Roots: []string{dir}})
+		evaluated := evaluator.Eval(env, testutil.SetupProgram(t, input, 0))
+		assert.Equal(t, "[[a.txt, b.txt], [a.txt], false, HELLO, true]", evaluated.Inspect())
+
+		denied := testutil.TestEval(t, fmt.Sprintf(`read_file("%s/a.txt")`, dir))
+		testutil.AssertErrorMessage(t, denied, &object.Error{Message: "`read_file` is not allowed: file access is disabled"})
+	})
+
+	t.Run("time and random", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`random_int(3, 3)`, 3},
+			{`random_int(2, 1)`, &object.Error{Message: "empty range passed to `random_int`: 2 > 1"}},
+			{`random_int(1, "2")`, &object.Error{Message: "argument to `random_int` must be an INTEGER, got STRING"}},
+			{`sleep(-1)`, &object.Error{Message: "duration passed to `sleep` must not be negative, got -1"}},
+			{`sleep(0)`, nil},
+			{`shuffle(1)`, &object.Error{Message: "argument to `shuffle` must be an ARRAY, got INTEGER"}},
+			{`shuffle([])`, []int{}},
+			{`len(shuffle([1, 2, 3]))`, 3},
+			{`now(1)`, &object.Error{Message: "wrong number of arguments. got=1, want=0"}},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+
+		input := `let start = now();
+sleep(1500);
+let rolls = map([1, 2, 3, 4, 5, 6, 7, 8], fn(x) { random_int(1, 6) });
+[now() - start, now(), len(filter(rolls, fn(r) { if (r < 1) { true } else { r > 6 } })), shuffle([1, 2, 3, 4, 5])]`
+		start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		run := func() string {
+			env := object.NewEnvironment()
+			env.Runtime().SetClock(object.NewFixedClock(start))
+			env.Runtime().SetRandom(object.NewSeededRandom(7))
+
+			result := evaluator.Eval(env, testutil.SetupProgram(t, input, 0)).(*object.Array)
+			testutil.AssertIntegerObject(t, result.At(0), 1500)
+			testutil.AssertIntegerObject(t, result.At(1), start.UnixMilli()+1500)
+			testutil.AssertIntegerObject(t, result.At(2), 0)
+			return result.Inspect()
+		}
+
+		assert.Equal(t, run(), run(), "runs with the same seed should match")
+	})
+
+	t.Run("callbacks", func(t *testing.T) {
+		tests := []struct {
+			input    string
+			expected any
+		}{
+			{`map([1, 2, 3], fn(x) { x * 2 })`, []int{2, 4, 6}},
+			{`map([], fn(x) { x })`, []int{}},
+			{`let offset = 10; map([1, 2], fn(x) { x + offset })`, []int{11, 12}},
+			{`map([[1, 2], [3]], fn(xs) { reduce(map(xs, fn(x) { x * x }), 0, fn(acc, x) { acc + x }) })`, []int{5, 9}},
+			{`map(["a", "bc"], len)`, []int{1, 2}},
+			{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, []int{3, 4}},
+			{`filter([1, 2, 3], fn(x) { if (x == 2) { true } })`, []int{2}},
+			{`reduce([1, 2, 3, 4], 0, fn(acc, x) { acc + x })`, 10},
+			{`reduce([], 7, fn(acc, x) { acc + x })`, 7},
+			{`each([1, 2], fn(x) { x })`, nil},
+			{`sort_by([3, 1, 2], fn(x) { x })`, []int{1, 2, 3}},
+			{`sort_by([21, 12, 11, 22], fn(x) { if (x < 20) { 1 } else { 2 } })`, []int{12, 11, 21, 22}},
+			{`sort_by([3, 10, 2], fn(x) { if (x == 10) { "a" } else { "b" } })`, []int{10, 3, 2}},
+			{`sort_by([1, 2], fn(x) { if (x == 1) { "a" } else { 2 } })`, &object.Error{Message: "sort keys must all be INTEGERs or all be STRINGs"}},
+			{`map(1, fn(x) { x })`, &object.Error{Message: "argument to `map` must be an ARRAY, got INTEGER"}},
+			{`filter([1], 1)`, &object.Error{Message: "argument to `filter` must be a FUNCTION, got INTEGER"}},
+			{`map([1, 2], fn(x) { x + true })`, &object.Error{Message: "type mismatch: INTEGER + BOOLEAN"}},
+			{`map([1], fn(x, y) { x })`, &object.Error{Message: "wrong number of arguments: want=2, got=1"}},
+			{`let r = map([1, 2], fn(x) { x + true }); 5`, &object.Error{Message: "type mismatch: INTEGER + BOOLEAN"}},
+		}
+
+		for _, tt := range tests {
+			testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+		}
+	})
+
+	t.Run("push and rest", func(t *testing.T) {
+		input := `
+let build = fn(arr, n) { if (n == 0) { arr } else { build(push(arr, n), n - 1) } };
+let drop = fn(arr, n) { if (n == 0) { arr } else { drop(rest(arr), n - 1) } };
+let arr = build([], 5000);
+[len(arr), first(drop(arr, 4000)), last(arr), len(drop(arr, 4999)), first(arr)]`
+
+		testutil.AssertIntegerArray(t, testutil.TestEval(t, input), []int{5000, 1000, 1, 1, 5000})
+	})
 }
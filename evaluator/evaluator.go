@@ -4,15 +4,20 @@ import (
 	"fmt"
 	"monkey/ast"
 	"monkey/object"
+	"monkey/token"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
 )
 
 func Eval(env *object.Environment, node ast.Node) object.Object {
+	if err := env.Runtime().Step(); err != nil {
+		return newError(object.RuntimeError, "%s", err)
+	}
+
 	switch node := node.(type) {
 	// Statements
 	case *ast.Program:
@@ -21,6 +26,8 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 		return evalBlockStatements(env, node)
 	case *ast.IfExpression:
 		return evalIfStatement(env, node)
+	case *ast.ForExpression:
+		return evalForExpression(env, node)
 	case *ast.LetStatement:
 		val := Eval(env, node.Value)
 		if isError(val) {
@@ -42,7 +49,10 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		if err := raiseOperand(right); err != nil {
+			return err
+		}
+		return withPos(evalPrefixExpression(env.Runtime().Arithmetic(), node.Operator, right), node.Token.Pos)
 	case *ast.InfixExpression:
 		left := Eval(env, node.Left)
 		if isError(left) {
@@ -52,7 +62,10 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		if err := raiseOperand(left, right); err != nil {
+			return err
+		}
+		return withPos(evalInfixExpression(env.Runtime().Arithmetic(), node.Operator, left, right), node.Token.Pos)
 	case *ast.CallExpression:
 		return evalCallExpression(env, node)
 	case *ast.IndexExpression:
@@ -64,22 +77,27 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 		if isError(index) {
 			return index
 		}
-		return evalIndexExpression(left, index)
+		if err := raiseOperand(left, index); err != nil {
+			return err
+		}
+		return withPos(evalIndexExpression(left, index), node.Token.Pos)
 	// Literals
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Parameters: params, Body: body, Env: env, Name: node.Name}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.BigIntegerLiteral:
+		return &object.BigInt{Value: node.Value}
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(env, node.Elements)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
-		return &object.Array{Elements: elements}
+		return object.NewArray(elements)
 	case *ast.HashLiteral:
 		return evalHashLiteral(env, node)
 	case *ast.Boolean:
@@ -100,7 +118,9 @@ func evalIdentifier(env *object.Environment, node *ast.Identifier) object.Object
 		return builtin
 	}
 
-	return newError("identifier not found: %s", node.Value)
+	err := newError(object.NameError, "identifier not found: %s", node.Value)
+	err.Pos = node.Token.Pos
+	return err
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -118,33 +138,41 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	return FALSE
 }
 
-func isTruthy(obj object.Object) bool {
-	switch obj {
-	case NULL:
-		return false
-	case TRUE:
-		return true
-	case FALSE:
-		return false
-	default:
-		return true
-	}
+// newError returns a raised error, which aborts evaluation.
+func newError(kind, format string, a ...any) *object.Error {
+	return &object.Error{Kind: kind, Message: fmt.Sprintf(format, a...), Raised: true}
 }
 
-func newError(format string, a ...any) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+// withPos records pos as the position of obj if it is an error that doesn't
+// have one yet.
+func withPos(obj object.Object, pos token.Position) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Pos == (token.Position{}) {
+		err.Pos = pos
+	}
+
+	return obj
 }
 
-func isError(obj object.Object) bool {
-	if obj != nil {
-		return obj.Type() == object.ERROR_OBJ
+// raiseOperand raises the first operand that is an error value, so using the
+// error returned by a builtin stops evaluation like it does in the VM.
+func raiseOperand(operands ...object.Object) object.Object {
+	for _, operand := range operands {
+		if err, ok := operand.(*object.Error); ok {
+			return err.Raise()
+		}
 	}
 
-	return false
+	return nil
+}
+
+// isError reports whether obj is an error that aborts evaluation.
+func isError(obj object.Object) bool {
+	err, ok := obj.(*object.Error)
+	return ok && err.Raised
 }
 
 func evalHashLiteral(env *object.Environment, node *ast.HashLiteral) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair, len(node.Pairs))
+	hash := &object.Hash{}
 
 	for keyNode, valueNode := range node.Pairs {
 		key := Eval(env, keyNode)
@@ -152,9 +180,8 @@ func evalHashLiteral(env *object.Environment, node *ast.HashLiteral) object.Obje
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
-			return newError("unusable as a hash key: %s", key.Type())
+		if _, ok := object.HashKeyOf(key); !ok {
+			return newError(object.TypeError, "unusable as a hash key: %s", key.Type())
 		}
 
 		value := Eval(env, valueNode)
@@ -162,9 +189,8 @@ func evalHashLiteral(env *object.Environment, node *ast.HashLiteral) object.Obje
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(key, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
@@ -1,9 +1,11 @@
 package evaluator_test
 
 import (
+	"math/big"
 	"monkey/evaluator"
 	"monkey/object"
 	"monkey/testutil"
+	"monkey/token"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,9 +63,9 @@ func TestArrayLiterals(t *testing.T) {
 	evaluated := testutil.TestEval(t, input)
 	array, ok := evaluated.(*object.Array)
 	require.Truef(t, ok, "object is not Array, got %T (%+v)", evaluated, evaluated)
-	testutil.AssertIntegerObject(t, array.Elements[0], 1)
-	testutil.AssertIntegerObject(t, array.Elements[1], 4)
-	testutil.AssertIntegerObject(t, array.Elements[2], 6)
+	testutil.AssertIntegerObject(t, array.At(0), 1)
+	testutil.AssertIntegerObject(t, array.At(1), 4)
+	testutil.AssertIntegerObject(t, array.At(2), 6)
 }
 
 func TestArrayIndexExpressions(t *testing.T) {
@@ -118,6 +120,24 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{`[1, 2] == [1, 2]`, true},
+		{`[1, 2] != [1, 2]`, false},
+		{`[1, 2] == [2, 1]`, false},
+		{`[1, 2] == [1, 2, 3]`, false},
+		{`[[1, "a"], [true]] == [[1, "a"], [true]]`, true},
+		{`[[1, "a"], [true]] == [[1, "b"], [true]]`, false},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{"a": {"b": [1]}} == {"a": {"b": [1]}}`, true},
+		{`"monkey" == "mon" + "key"`, true},
+		{`"monkey" != "monkey"`, false},
+		{`[9223372036854775807 + 1] == [9223372036854775808]`, true},
+		{`[] == []`, true},
+		{`[1] == 1`, false},
+		{`[if (false) { 1 }] == [if (false) { 2 }]`, true},
+		{`let f = fn() { 1 }; f == f`, true},
+		{`fn() { 1 } == fn() { 1 }`, false},
 	}
 
 	for _, tt := range tests {
@@ -220,6 +240,9 @@ func TestErrorHandling(t *testing.T) {
 		{"foobar", "identifier not found: foobar"},
 		{`"Hello" - "World"`, "unknown operator: STRING - STRING"},
 		{`{"name": "Monkey"}[fn(x) { x }]`, "unusable as hash key: FUNCTION"},
+		{`{"name": "Monkey"}[[fn(x) { x }]]`, "unusable as hash key: ARRAY"},
+		{"1 / 0", "division by zero: 1 / 0"},
+		{"let f = fn(x) { 10 / x }; f(0)", "division by zero: 10 / 0"},
 	}
 	for _, tt := range tests {
 		evaluated := testutil.TestEval(t, tt.input)
@@ -229,6 +252,60 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedKind string
+		expectedPos  token.Position
+	}{
+		{"5 + true;", object.TypeError, token.Position{Line: 1, Column: 3}},
+		{"let x = 1;\n  -true", object.TypeError, token.Position{Line: 2, Column: 3}},
+		{"foobar", object.NameError, token.Position{Line: 1, Column: 1}},
+		{"1 / 0", object.ArithmeticError, token.Position{Line: 1, Column: 3}},
+		{"fn(x) { x }()", object.ArgumentError, token.Position{}},
+		{"[1][true]", object.TypeError, token.Position{Line: 1, Column: 4}},
+		{"len(1)", object.TypeError, token.Position{Line: 1, Column: 1}},
+		{`error("Custom", "message")`, "Custom", token.Position{Line: 1, Column: 1}},
+	}
+
+	for _, tt := range tests {
+		evaluated := testutil.TestEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		require.Truef(t, ok, "no error object returned, got %T (%+v)", evaluated, evaluated)
+		assert.Equal(t, tt.expectedKind, errObj.Kind, tt.input)
+		assert.Equal(t, tt.expectedPos, errObj.Pos, tt.input)
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	input := `let head = fn(x) { first(x) };
+let wrapper = fn(y) {
+	head(y) + 1
+};
+wrapper(5);`
+
+	evaluated := testutil.TestEval(t, input)
+	errObj, ok := evaluated.(*object.Error)
+	require.Truef(t, ok, "no error object returned, got %T (%+v)", evaluated, evaluated)
+	assert.Equal(t, "argument to `first` must be an ARRAY, got INTEGER", errObj.Message)
+	assert.Equal(t, object.Stack{
+		{Function: "first", Pos: token.Position{Line: 1, Column: 20}},
+		{Function: "head", Pos: token.Position{Line: 3, Column: 2}},
+		{Function: "wrapper", Pos: token.Position{Line: 5, Column: 1}},
+	}, errObj.Stack)
+
+	t.Run("wrong number of arguments", func(t *testing.T) {
+		evaluated := testutil.TestEval(t, `let add = fn(a, b) { a + b }; fn() { add(1) * 2 }();`)
+		errObj, ok := evaluated.(*object.Error)
+		require.Truef(t, ok, "no error object returned, got %T (%+v)", evaluated, evaluated)
+		assert.Equal(t, "wrong number of arguments: want=2, got=1", errObj.Message)
+		assert.Equal(t, object.Stack{
+			{Function: "add", Pos: token.Position{Line: 1, Column: 38}},
+			{Function: "", Pos: token.Position{Line: 1, Column: 50}},
+		}, errObj.Stack)
+	})
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -245,6 +322,65 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestForExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"let total = 0; for (i in range(1, 11)) { let total = total + i }; total", 55},
+		{"let f = fn() { let acc = []; for (i in range(9, 0, -2)) { let acc = push(acc, i) }; acc }; f()", []int{9, 7, 5, 3, 1}},
+		{`let acc = []; for (c in "héllo") { let acc = push(acc, c) }; acc`, []string{"h", "é", "l", "l", "o"}},
+		{`let acc = []; for (k in {"b": 2, "a": 1, "c": 3}) { let acc = push(acc, k) }; acc`, []string{"a", "b", "c"}},
+		{"let find = fn(xs, n) { for (x in xs) { if (x == n) { return true } }; false }; find([1, 2, 3], 2)", true},
+		{"let find = fn(xs, n) { for (x in xs) { if (x == n) { return true } }; false }; find([1, 2, 3], 4)", false},
+		{"let total = 0; for (x in [1, 2]) { for (y in [10, 20]) { let total = total + x * y } }; total", 90},
+		{"for (x in [1]) { x }", nil},
+		{"let f = fn() { let fs = []; for (i in range(3)) { let fs = push(fs, fn() { i }) }; map(fs, fn(g) { g() }) }; f()", []int{0, 1, 2}},
+		{"let fs = []; for (i in range(3)) { let fs = push(fs, fn() { i }) }; map(fs, fn(g) { g() })", []int{0, 1, 2}},
+		{"let fs = []; for (i in range(2)) { let fs = push(fs, fn() { fn() { i } }) }; map(fs, fn(g) { g()() })", []int{0, 1}},
+		{"let fs = []; for (i in range(2)) { for (j in range(2)) { let fs = push(fs, fn() { i * 10 + j }) } }; map(fs, fn(g) { g() })", []int{0, 1, 10, 11}},
+		{"for (i in range(3)) { i }; i", 2},
+		{"let f = fn() { for (i in range(3)) { i }; i }; f()", 2},
+		{"for (i in range(3)) { let i = i * 10 }; i", 20},
+		{"len(range(0, 9223372036854775807))", 9223372036854775807},
+		{"len(range(9223372036854775807, -9223372036854775807 - 1, -9223372036854775807 - 1))", 2},
+		{
+			"range(-1, 9223372036854775807)",
+			&object.Error{Kind: object.ArgumentError, Message: "range passed to `range` is too long: more than 9223372036854775807 values"},
+		},
+		{
+			"range(-9223372036854775807 - 1, 9223372036854775807)",
+			&object.Error{Kind: object.ArgumentError, Message: "range passed to `range` is too long: more than 9223372036854775807 values"},
+		},
+		{"len(range(0, 100, 7))", 15},
+		{"range(3) == range(0, 3, 1)", true},
+		{
+			"range(1, 2, 0)",
+			&object.Error{Kind: object.ArgumentError, Message: "step passed to `range` must not be zero"},
+		},
+	}
+
+	for _, tt := range tests {
+		testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+	}
+
+	t.Run("not iterable", func(t *testing.T) {
+		evaluated := testutil.TestEval(t, "let x = 5;\nfor (i in x) { i }")
+		errObj, ok := evaluated.(*object.Error)
+		require.Truef(t, ok, "no error object returned, got %T (%+v)", evaluated, evaluated)
+		assert.Equal(t, object.TypeError, errObj.Kind)
+		assert.Equal(t, "cannot iterate over INTEGER", errObj.Message)
+		assert.Equal(t, token.Position{Line: 2, Column: 1}, errObj.Pos)
+	})
+
+	t.Run("errors stop the loop", func(t *testing.T) {
+		evaluated := testutil.TestEval(t, "for (x in [1, true, 3]) { x + 1 }")
+		errObj, ok := evaluated.(*object.Error)
+		require.Truef(t, ok, "no error object returned, got %T (%+v)", evaluated, evaluated)
+		assert.Equal(t, "type mismatch: BOOLEAN + INTEGER", errObj.Message)
+	})
+}
+
 func TestFunctions(t *testing.T) {
 	t.Run("function object", func(t *testing.T) {
 
@@ -288,6 +424,53 @@ func TestFunctions(t *testing.T) {
 	})
 }
 
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{
+			`let count = fn(n, acc) {
+				if (n == 0) { return acc; }
+				count(n - 1, acc + 1);
+			};
+			count(1000000, 0);`,
+			1000000,
+		},
+		{
+			`let count = fn(n) {
+				if (n == 0) { 0 } else { return count(n - 1); }
+			};
+			count(100000);`,
+			0,
+		},
+		{
+			`let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+			let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+			if (isEven(100001)) { 1 } else { 0 };`,
+			0,
+		},
+		{
+			`let sum = fn(n) { if (n == 0) { 0 } else { n + sum(n - 1) } };
+			sum(100);`,
+			5050,
+		},
+		{
+			`let early = fn(n) {
+				if (n > 0) { return early(n - 1); }
+				let x = 10;
+				x;
+			};
+			early(5);`,
+			10,
+		},
+	}
+
+	for _, tt := range tests {
+		testutil.AssertIntegerObject(t, testutil.TestEval(t, tt.input), tt.expected)
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
 	{
@@ -299,8 +482,6 @@ func TestHashLiterals(t *testing.T) {
 		false: 6,
 	}`
 	evaluated := testutil.TestEval(t, input)
-	result, ok := evaluated.(*object.Hash)
-	require.Truef(t, ok, "Eval didn't return a Hash, got %T (%+v)", evaluated, evaluated)
 
 	expected := map[object.HashKey]int64{
 		(&object.String{Value: "one"}).HashKey():   1,
@@ -310,12 +491,7 @@ func TestHashLiterals(t *testing.T) {
 		evaluator.TRUE.HashKey():                   5,
 		evaluator.FALSE.HashKey():                  6,
 	}
-	assert.Len(t, result.Pairs, len(expected))
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
-		assert.Truef(t, ok, "No pair for given key in Pairs")
-		testutil.AssertIntegerObject(t, pair.Value, expectedValue)
-	}
+	testutil.AssertIntegerHash(t, evaluated, expected)
 }
 
 func TestHashIndexExpressions(t *testing.T) {
@@ -325,6 +501,11 @@ func TestHashIndexExpressions(t *testing.T) {
 	}{
 		{`{"foo": 5}["foo"]`, 5},
 		{`{"foo": 5}["bar"]`, nil},
+		{`{[1, 2]: 12}[[1, 2]]`, 12},
+		{`{[1, [2, 3]]: 123}[[1, [2, 3]]]`, 123},
+		{`{[1, 2]: 12}[[2, 1]]`, nil},
+		{`{{"a": 1, "b": 2}: 3}[{"b": 2, "a": 1}]`, 3},
+		{`{[9223372036854775808]: 5}[[9223372036854775807 + 1]]`, 5},
 		{`let key = "foo"; {"foo": 5}[key]`, 5},
 		{`{}["foo"]`, nil},
 		{`{5: 5}[5]`, 5},
@@ -342,3 +523,70 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775806 + 1", 9223372036854775807},
+		{"9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", "integer overflow: -9223372036854775807 - 2"},
+		{"4611686018427387904 * 2", "integer overflow: 4611686018427387904 * 2"},
+		{"let min = -9223372036854775807 - 1; -min", "integer overflow: -(-9223372036854775808)"},
+		{"let min = -9223372036854775807 - 1; min / -1", "integer overflow: -9223372036854775808 / -1"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Runtime().SetArithmetic(object.CheckedArithmetic)
+		evaluated := evaluator.Eval(env, testutil.SetupProgram(t, tt.input, 0))
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testutil.AssertIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.Truef(t, ok, "no error object returned, got %T (%+v)", evaluated, evaluated)
+			assert.Equal(t, expected, errObj.Message)
+		}
+	}
+
+	t.Run("wrapping", func(t *testing.T) {
+		env := object.NewEnvironment()
+		env.Runtime().SetArithmetic(object.WrappingArithmetic)
+		evaluated := evaluator.Eval(env, testutil.SetupProgram(t, "9223372036854775807 + 1", 0))
+		testutil.AssertIntegerObject(t, evaluated, -9223372036854775808)
+	})
+}
+
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"9223372036854775807 + 1", bigInt("9223372036854775808")},
+		{"-9223372036854775807 - 2", bigInt("-9223372036854775809")},
+		{"4611686018427387904 * 4", bigInt("18446744073709551616")},
+		{"let min = -9223372036854775807 - 1; -min", bigInt("9223372036854775808")},
+		{"123456789012345678901234567890", bigInt("123456789012345678901234567890")},
+		{"123456789012345678901234567890 / 10", bigInt("12345678901234567890123456789")},
+		{"9223372036854775808 - 1", 9223372036854775807},
+		{"-(9223372036854775807 + 1) / 2", -4611686018427387904},
+		{"9223372036854775808 > 9223372036854775807", true},
+		{"9223372036854775807 < 9223372036854775808", true},
+		{"9223372036854775808 == 9223372036854775807 + 1", true},
+		{"9223372036854775808 != 1", true},
+		{`{9223372036854775808: "big"}[9223372036854775807 + 1]`, "big"},
+		{"9223372036854775808 / 0", &object.Error{Message: "division by zero: 9223372036854775808 / 0"}},
+	}
+
+	for _, tt := range tests {
+		testutil.AssertObject(t, testutil.TestEval(t, tt.input), tt.expected)
+	}
+}
+
+func bigInt(value string) *big.Int {
+	n, _ := new(big.Int).SetString(value, 10)
+	return n
+}
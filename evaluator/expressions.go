@@ -5,78 +5,69 @@ import (
 	"monkey/object"
 )
 
-func evalPrefixExpression(operator string, right object.Object) object.Object {
+func evalPrefixExpression(mode object.ArithmeticMode, operator string, right object.Object) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
-		return evalMinusPrefixExpression(right)
+		return evalMinusPrefixExpression(mode, right)
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return newError(object.TypeError, "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
 func evalBangOperatorExpression(right object.Object) object.Object {
-	switch right {
-	case TRUE:
-		return FALSE
-	case FALSE:
-		return TRUE
-	case NULL:
-		return TRUE
-	default:
-		return FALSE
-	}
+	return nativeBoolToBooleanObject(!object.IsTruthy(right))
 }
 
-func evalMinusPrefixExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
+func evalMinusPrefixExpression(mode object.ArithmeticMode, right object.Object) object.Object {
+	if !object.IsInteger(right) {
+		return newError(object.TypeError, "unknown operator: -%s", right.Type())
 	}
 
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
+	result, err := mode.Negate(right)
+	if err != nil {
+		return newError(object.ArithmeticError, "%s: -(%s)", err, right.Inspect())
+	}
+
+	return result
 }
 
-func evalInfixExpression(operator string, left, right object.Object) object.Object {
+func evalInfixExpression(mode object.ArithmeticMode, operator string, left, right object.Object) object.Object {
 	switch {
-	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
+	case object.IsInteger(left) && object.IsInteger(right):
+		return evalIntegerInfixExpression(mode, operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equals(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equals(left, right))
 	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TypeError, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
-	leftVal := left.(*object.Integer).Value
-	rightVal := right.(*object.Integer).Value
+func evalIntegerInfixExpression(mode object.ArithmeticMode, operator string, left, right object.Object) object.Object {
 	switch operator {
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
-	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+	case "+", "-", "*", "/":
+		result, err := mode.Infix(operator, left, right)
+		if err != nil {
+			return newError(object.ArithmeticError, "%s: %s %s %s", err, left.Inspect(), operator, right.Inspect())
+		}
+		return result
 	case ">":
-		return nativeBoolToBooleanObject(leftVal > rightVal)
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) > 0)
 	case "<":
-		return nativeBoolToBooleanObject(leftVal < rightVal)
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) < 0)
 	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) == 0)
 	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) != 0)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
@@ -91,7 +82,7 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
@@ -117,42 +108,59 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newError(object.TypeError, "index operator not supported: %s", left.Type())
 	}
 }
 
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arr := array.(*object.Array)
 	idx := index.(*object.Integer).Value
-	max := int64(len(arr.Elements) - 1)
+	max := int64(arr.Len() - 1)
 
 	if idx < 0 || idx > max {
 		return NULL
 	}
 
-	return arr.Elements[idx]
+	return arr.At(int(idx))
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
-	key, ok := index.(object.Hashable)
-	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+	if _, ok := object.HashKeyOf(index); !ok {
+		return newError(object.TypeError, "unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	value, ok := hashObject.Get(index)
 	if !ok {
 		return NULL
 	}
 
-	return pair.Value
+	return value
+}
+
+// isSpecialForm reports whether exp is a call to `quote` or `macroexpand`,
+// whose arguments are not evaluated.
+func isSpecialForm(exp *ast.CallExpression) bool {
+	name := exp.Function.TokenLiteral()
+	return name == "quote" || name == "macroexpand"
 }
 
 func evalCallExpression(env *object.Environment, exp *ast.CallExpression) object.Object {
 	if exp.Function.TokenLiteral() == "quote" {
+		if len(exp.Arguments) != 1 {
+			return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(exp.Arguments))
+		}
 		return quote(env, exp.Arguments[0])
 	}
 
+	if exp.Function.TokenLiteral() == "macroexpand" {
+		if len(exp.Arguments) != 1 {
+			return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(exp.Arguments))
+		}
+		// Macro calls in the argument have already been expanded by ExpandMacros
+		return &object.Quote{Node: exp.Arguments[0]}
+	}
+
 	function := Eval(env, exp.Function)
 	if isError(function) {
 		return function
@@ -163,23 +171,43 @@ func evalCallExpression(env *object.Environment, exp *ast.CallExpression) object
 		return args[0]
 	}
 
-	return applyFunction(function, args)
+	return applyFunction(env.Runtime(), function, args, exp)
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
-	switch fn := fn.(type) {
-	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(extendedEnv, fn.Body)
-		return unwrapReturnValue(evaluated)
-	case *object.Builtin:
-		if result := fn.Fn(args...); result != nil {
-			return result
+func applyFunction(runtime *object.Runtime, fn object.Object, args []object.Object, call *ast.CallExpression) object.Object {
+	frame := newStackFrame(fn, call)
+	if err := runtime.PushCall(frame); err != nil {
+		return withStack(runtime, newError(object.RuntimeError, "maximum call depth of %d exceeded calling %s", runtime.MaxCallDepth(), frame.Function))
+	}
+	defer runtime.PopCall()
+
+	for {
+		switch function := fn.(type) {
+		case *object.Function:
+			if len(args) != len(function.Parameters) {
+				return withStack(runtime, newError(object.ArgumentError, "wrong number of arguments: want=%d, got=%d", len(function.Parameters), len(args)))
+			}
+
+			extendedEnv := extendFunctionEnv(function, args)
+			evaluated := evalFunctionBody(extendedEnv, function.Body)
+			if tc, ok := evaluated.(*tailCall); ok {
+				// Replacing the call doesn't nest deeper, so this can't fail
+				runtime.PopCall()
+				_ = runtime.PushCall(newStackFrame(tc.fn, tc.call))
+				fn, args = tc.fn, tc.args
+				continue
+			}
+
+			return withStack(runtime, unwrapReturnValue(evaluated))
+		case *object.Builtin:
+			if result := function.Fn(&engine{runtime: runtime, call: call}, args...); result != nil {
+				return withStack(runtime, withPos(result, newStackFrame(function, call).Pos))
+			}
+
+			return NULL
+		default:
+			return withStack(runtime, newError(object.TypeError, "not a function: %s", fn.Type()))
 		}
-
-		return NULL
-	default:
-		return newError("not a function: %s", fn.Type())
 	}
 }
 
@@ -192,3 +220,30 @@ func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Enviro
 
 	return env
 }
+
+// newStackFrame describes calling fn at call for tracebacks.
+func newStackFrame(fn object.Object, call *ast.CallExpression) object.StackFrame {
+	frame := object.StackFrame{Pos: call.Token.Pos}
+	if identifier, ok := call.Function.(*ast.Identifier); ok {
+		frame.Pos = identifier.Token.Pos
+	}
+
+	switch fn := fn.(type) {
+	case *object.Function:
+		frame.Function = fn.Name
+	case *object.Builtin:
+		frame.Function = object.BuiltinName(fn)
+	}
+
+	return frame
+}
+
+// withStack attaches the active calls to obj if it is an error that doesn't
+// have a stack yet, so the stack shows where the error occurred.
+func withStack(runtime *object.Runtime, obj object.Object) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Stack == nil {
+		err.Stack = runtime.CallStack()
+	}
+
+	return obj
+}
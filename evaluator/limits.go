@@ -0,0 +1,23 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/ast"
+	"monkey/object"
+)
+
+// EvalContext evaluates node like Eval, but stops once ctx is done or after
+// maxSteps steps, returning an error that wraps object.ErrCancelled or
+// object.ErrBudgetExceeded. A maxSteps of 0 means there is no limit.
+func EvalContext(ctx context.Context, env *object.Environment, node ast.Node, maxSteps int) (object.Object, error) {
+	runtime := env.Runtime()
+	runtime.Begin(ctx, maxSteps)
+	defer runtime.End()
+
+	result := Eval(env, node)
+	if err := runtime.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package evaluator_test
+
+import (
+	"context"
+	"monkey/evaluator"
+	"monkey/object"
+	"monkey/testutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalContext(t *testing.T) {
+	forever := `let loop = fn(n) { loop(n + 1) }; loop(0);`
+
+	t.Run("finishes within budget", func(t *testing.T) {
+		program := testutil.SetupProgram(t, `let add = fn(a, b) { a + b }; add(1, 2);`, 0)
+		result, err := evaluator.EvalContext(context.Background(), object.NewEnvironment(), program, 1000)
+		require.NoError(t, err)
+		testutil.AssertIntegerObject(t, result, 3)
+	})
+
+	t.Run("budget exceeded", func(t *testing.T) {
+		program := testutil.SetupProgram(t, forever, 0)
+		_, err := evaluator.EvalContext(context.Background(), object.NewEnvironment(), program, 10000)
+		assert.ErrorIs(t, err, object.ErrBudgetExceeded)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		program := testutil.SetupProgram(t, forever, 0)
+		_, err := evaluator.EvalContext(ctx, object.NewEnvironment(), program, 0)
+		assert.ErrorIs(t, err, object.ErrCancelled)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("already cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		program := testutil.SetupProgram(t, `1 + 1`, 0)
+		_, err := evaluator.EvalContext(ctx, object.NewEnvironment(), program, 0)
+		assert.ErrorIs(t, err, object.ErrCancelled)
+	})
+
+	t.Run("limits end with the run", func(t *testing.T) {
+		env := object.NewEnvironment()
+		program := testutil.SetupProgram(t, `let a = 1; a + a`, 0)
+		_, err := evaluator.EvalContext(context.Background(), env, program, 1)
+		assert.ErrorIs(t, err, object.ErrBudgetExceeded)
+
+		program = testutil.SetupProgram(t, `let a = 1; a + a`, 0)
+		testutil.AssertIntegerObject(t, evaluator.Eval(env, program), 2)
+	})
+}
+
+func TestCallDepth(t *testing.T) {
+	t.Run("unbounded recursion", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		program := testutil.SetupProgram(t, `let f = fn(n) { 1 + f(n + 1) }; f(0)`, 0)
+		result, err := evaluator.EvalContext(ctx, object.NewEnvironment(), program, 0)
+		require.NoError(t, err)
+
+		errObj, ok := result.(*object.Error)
+		require.Truef(t, ok, "no error object returned, got %T (%+v)", result, result)
+		assert.Equal(t, object.RuntimeError, errObj.Kind)
+		assert.Equal(t, "maximum call depth of 1024 exceeded calling f", errObj.Message)
+		assert.Len(t, errObj.Stack, object.DefaultMaxCallDepth)
+	})
+
+	t.Run("recursion through builtins", func(t *testing.T) {
+		evaluated := testutil.TestEval(t, `let f = fn(n) { map([n], f) }; f(0)`)
+		errObj, ok := evaluated.(*object.Error)
+		require.Truef(t, ok, "no error object returned, got %T (%+v)", evaluated, evaluated)
+		assert.Equal(t, "maximum call depth of 1024 exceeded calling f", errObj.Message)
+	})
+
+	t.Run("configurable", func(t *testing.T) {
+		input := `let depth = fn(n) { if (n == 0) { 0 } else { 1 + depth(n - 1) } };`
+
+		env := object.NewEnvironment()
+		env.Runtime().SetMaxCallDepth(10)
+		testutil.AssertIntegerObject(t, evaluator.Eval(env, testutil.SetupProgram(t, input+"depth(9)", 0)), 9)
+
+		evaluated := evaluator.Eval(env, testutil.SetupProgram(t, input+"depth(10)", 0))
+		errObj, ok := evaluated.(*object.Error)
+		require.Truef(t, ok, "no error object returned, got %T (%+v)", evaluated, evaluated)
+		assert.Equal(t, "maximum call depth of 10 exceeded calling depth", errObj.Message)
+	})
+
+	t.Run("tail calls don't nest", func(t *testing.T) {
+		env := object.NewEnvironment()
+		env.Runtime().SetMaxCallDepth(10)
+		program := testutil.SetupProgram(t, `let loop = fn(n) { if (n == 0) { 0 } else { loop(n - 1) } }; loop(1000)`, 0)
+		testutil.AssertIntegerObject(t, evaluator.Eval(env, program), 0)
+	})
+}
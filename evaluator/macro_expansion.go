@@ -1,10 +1,41 @@
 package evaluator
 
 import (
+	"fmt"
 	"monkey/ast"
 	"monkey/object"
+	"monkey/token"
 )
 
+// MaxMacroExpansionDepth limits how often the result of a macro call is
+// expanded again, which stops macros that expand into calls to themselves.
+const MaxMacroExpansionDepth = 128
+
+// MacroError is returned when a macro call cannot be expanded.
+type MacroError struct {
+	Macro  string
+	Pos    token.Position
+	Reason string
+}
+
+func (e *MacroError) Error() string {
+	return fmt.Sprintf("%s: cannot expand macro %s: %s", e.Pos, e.Macro, e.Reason)
+}
+
+// ProcessMacros is the front-end phase shared by both engines: it defines the
+// macros found in program in env and returns the program with every macro
+// call expanded. Macros stay defined in env for the programs that follow.
+func ProcessMacros(env *object.Environment, program *ast.Program) (*ast.Program, error) {
+	DefineMacros(env, program)
+
+	expanded, err := ExpandMacros(env, program)
+	if err != nil {
+		return nil, err
+	}
+
+	return expanded.(*ast.Program), nil
+}
+
 func DefineMacros(env *object.Environment, program *ast.Program) {
 	definitions := []int{}
 
@@ -47,8 +78,18 @@ func addMacro(env *object.Environment, stmt ast.Statement) {
 	env.Set(letStatement.Name.Value, macro)
 }
 
-func ExpandMacros(env *object.Environment, program ast.Node) ast.Node {
-	return ast.Modify(program, func(node ast.Node) ast.Node {
+func ExpandMacros(env *object.Environment, program ast.Node) (ast.Node, error) {
+	return expandMacros(env, program, 0)
+}
+
+func expandMacros(env *object.Environment, node ast.Node, depth int) (ast.Node, error) {
+	var err error
+
+	expanded := ast.Modify(node, func(node ast.Node) ast.Node {
+		if err != nil {
+			return node
+		}
+
 		callExpression, ok := node.(*ast.CallExpression)
 		if !ok {
 			return node
@@ -59,17 +100,41 @@ func ExpandMacros(env *object.Environment, program ast.Node) ast.Node {
 			return node
 		}
 
+		name := callExpression.Function.(*ast.Identifier)
+		fail := func(format string, a ...any) ast.Node {
+			err = &MacroError{Macro: name.Value, Pos: name.Token.Pos, Reason: fmt.Sprintf(format, a...)}
+			return node
+		}
+
+		if depth >= MaxMacroExpansionDepth {
+			return fail("maximum expansion depth of %d exceeded", MaxMacroExpansionDepth)
+		}
+		if len(callExpression.Arguments) != len(macro.Parameters) {
+			return fail("wrong number of arguments: want=%d, got=%d", len(macro.Parameters), len(callExpression.Arguments))
+		}
+
 		args := quoteArgs(callExpression)
 		evalEnv := extendMacroEnv(macro, args)
 
-		evaluated := Eval(evalEnv, macro.Body)
-		quote, ok := evaluated.(*object.Quote)
-		if !ok {
-			panic("we only support returning AST-nodes from macros")
+		evaluated := unwrapReturnValue(Eval(evalEnv, macro.Body))
+		switch evaluated := evaluated.(type) {
+		case *object.Quote:
+			var result ast.Node
+			result, err = expandMacros(env, evaluated.Node, depth+1)
+			return result
+		case *object.Error:
+			return fail("%s", evaluated.Message)
+		case nil:
+			return fail("macro must return a quote, got nothing")
+		default:
+			return fail("macro must return a quote, got %s", evaluated.Type())
 		}
-
-		return quote.Node
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expanded, nil
 }
 
 func isMacroCall(env *object.Environment, exp *ast.CallExpression) (*object.Macro, bool) {
@@ -61,6 +61,17 @@ func TestExpandMacros(t *testing.T) {
 			unless(10 > 5, puts("not greater"), puts("greater"));`,
 			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
 		},
+		{
+			`let thrice = macro(x) { quote([unquote_splice([x, x, x])]); };
+			thrice(1 + 1);`,
+			`[(1 + 1), (1 + 1), (1 + 1)]`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+			reverse(1, 10);
+			reverse(2, 20);`,
+			`(10 - 1); (20 - 2)`,
+		},
 	}
 
 	for _, tC := range testCases {
@@ -68,7 +79,92 @@ func TestExpandMacros(t *testing.T) {
 		program := testutil.SetupProgram(t, tC.input, 0)
 		env := object.NewEnvironment()
 		evaluator.DefineMacros(env, program)
-		expanded := evaluator.ExpandMacros(env, program)
+		expanded, err := evaluator.ExpandMacros(env, program)
+		require.NoError(t, err)
 		assert.EqualValues(t, expected.String(), expanded.String())
 	}
 }
+
+func TestExpandMacrosErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let two = macro(a, b) { quote(unquote(a) + unquote(b)); };
+			two(1);`,
+			"2:4: cannot expand macro two: wrong number of arguments: want=2, got=1",
+		},
+		{
+			`let notQuote = macro() { 1 };
+			notQuote();`,
+			"2:4: cannot expand macro notQuote: macro must return a quote, got INTEGER",
+		},
+		{
+			`let failing = macro() { first(1) };
+			failing();`,
+			"2:4: cannot expand macro failing: argument to `first` must be an ARRAY, got INTEGER",
+		},
+		{
+			`let forever = macro(x) { quote(forever(unquote(x))); };
+			forever(1);`,
+			"1:32: cannot expand macro forever: maximum expansion depth of 128 exceeded",
+		},
+	}
+
+	for _, tC := range testCases {
+		program := testutil.SetupProgram(t, tC.input, 0)
+		env := object.NewEnvironment()
+		evaluator.DefineMacros(env, program)
+		_, err := evaluator.ExpandMacros(env, program)
+		require.Error(t, err)
+		assert.Equal(t, tC.expected, err.Error())
+
+		var macroErr *evaluator.MacroError
+		assert.ErrorAs(t, err, &macroErr)
+	}
+}
+
+func TestProcessMacrosAcrossPrograms(t *testing.T) {
+	env := object.NewEnvironment()
+
+	definition := testutil.SetupProgram(t, `let double = macro(x) { quote(unquote(x) * 2); };`, 1)
+	_, err := evaluator.ProcessMacros(env, definition)
+	require.NoError(t, err)
+
+	program := testutil.SetupProgram(t, `double(21)`, 1)
+	expanded, err := evaluator.ProcessMacros(env, program)
+	require.NoError(t, err)
+	assert.Equal(t, "(21 * 2)", expanded.String())
+}
+
+func TestMacroexpand(t *testing.T) {
+	input := `
+	let unless = macro(condition, consequence, alternative) {
+		quote(if (!(unquote(condition))) { unquote(consequence); } else { unquote(alternative); });
+	};
+	macroexpand(unless(1 > 2, "yes", "no"));`
+
+	program := testutil.SetupProgram(t, input, 0)
+	program, err := evaluator.ProcessMacros(object.NewEnvironment(), program)
+	require.NoError(t, err)
+
+	evaluated := evaluator.Eval(object.NewEnvironment(), program)
+	quote, ok := evaluated.(*object.Quote)
+	require.Truef(t, ok, "expected *object.Quote, got %T (%+v)", evaluated, evaluated)
+	assert.Equal(t, `if(!(1 > 2)) yeselse no`, quote.Node.String())
+}
+
+func TestSharedMacroArguments(t *testing.T) {
+	// The macro puts the same call node in and out of tail position
+	input := `
+	let dup = macro(e) { quote(fn() { let a = unquote(e); if (a > 100) { unquote(e) } else { 42 } }) };
+	let f = fn(x) { x * 10 };
+	dup(f(1))();`
+
+	program := testutil.SetupProgram(t, input, 0)
+	program, err := evaluator.ProcessMacros(object.NewEnvironment(), program)
+	require.NoError(t, err)
+
+	testutil.AssertIntegerObject(t, evaluator.Eval(object.NewEnvironment(), program), 42)
+}
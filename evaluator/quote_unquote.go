@@ -8,28 +8,49 @@ import (
 )
 
 func quote(env *object.Environment, node ast.Node) object.Object {
-	node = evalUnquoteCalls(env, node)
+	node, err := evalUnquoteCalls(env, ast.Copy(node))
+	if err != nil {
+		return err
+	}
+
 	return &object.Quote{Node: node}
 }
 
-func evalUnquoteCalls(env *object.Environment, quoted ast.Node) ast.Node {
-	return ast.Modify(quoted, func(node ast.Node) ast.Node {
-		if !isUnquotedCall(node) {
-			return node
-		}
+func evalUnquoteCalls(env *object.Environment, quoted ast.Node) (ast.Node, *object.Error) {
+	var err *object.Error
 
-		call, ok := node.(*ast.CallExpression)
-		if !ok {
+	node := ast.Modify(quoted, func(node ast.Node) ast.Node {
+		if err != nil {
 			return node
 		}
 
-		if len(call.Arguments) != 1 {
-			return node
+		switch node := node.(type) {
+		case *ast.CallExpression:
+			if isUnquotedCall(node) {
+				if len(node.Arguments) != 1 {
+					return node
+				}
+
+				unquoted := Eval(env, node.Arguments[0])
+				if isError(unquoted) {
+					err = unquoted.(*object.Error)
+					return node
+				}
+
+				return convertObjectToASTNode(unquoted)
+			}
+
+			node.Arguments, err = spliceExpressions(env, node.Arguments)
+		case *ast.ArrayLiteral:
+			node.Elements, err = spliceExpressions(env, node.Elements)
+		case *ast.BlockStatement:
+			node.Statements, err = spliceStatements(env, node.Statements)
 		}
 
-		unquoted := Eval(env, call.Arguments[0])
-		return convertObjectToASTNode(unquoted)
+		return node
 	})
+
+	return node, err
 }
 
 func isUnquotedCall(node ast.Node) bool {
@@ -41,6 +62,100 @@ func isUnquotedCall(node ast.Node) bool {
 	return callExpression.Function.TokenLiteral() == "unquote"
 }
 
+func isUnquoteSpliceCall(node ast.Node) bool {
+	callExpression, ok := node.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+
+	return callExpression.Function.TokenLiteral() == "unquote_splice"
+}
+
+// spliceExpressions replaces every `unquote_splice(array)` in exps with the
+// nodes of the array's elements.
+func spliceExpressions(env *object.Environment, exps []ast.Expression) ([]ast.Expression, *object.Error) {
+	spliced := make([]ast.Expression, 0, len(exps))
+
+	for _, exp := range exps {
+		if !isUnquoteSpliceCall(exp) {
+			spliced = append(spliced, exp)
+			continue
+		}
+
+		nodes, err := evalUnquoteSplice(env, exp.(*ast.CallExpression))
+		if err != nil {
+			return exps, err
+		}
+
+		for _, node := range nodes {
+			expression, ok := node.(ast.Expression)
+			if !ok {
+				return exps, newError(object.TypeError, "cannot splice a statement into an expression: %s", node.String())
+			}
+			spliced = append(spliced, expression)
+		}
+	}
+
+	return spliced, nil
+}
+
+// spliceStatements replaces every `unquote_splice(array);` statement in
+// stmts with the nodes of the array's elements.
+func spliceStatements(env *object.Environment, stmts []ast.Statement) ([]ast.Statement, *object.Error) {
+	spliced := make([]ast.Statement, 0, len(stmts))
+
+	for _, stmt := range stmts {
+		exp, ok := stmt.(*ast.ExpressionStatement)
+		if !ok || !isUnquoteSpliceCall(exp.Expression) {
+			spliced = append(spliced, stmt)
+			continue
+		}
+
+		nodes, err := evalUnquoteSplice(env, exp.Expression.(*ast.CallExpression))
+		if err != nil {
+			return stmts, err
+		}
+
+		for _, node := range nodes {
+			switch node := node.(type) {
+			case ast.Statement:
+				spliced = append(spliced, node)
+			case ast.Expression:
+				spliced = append(spliced, &ast.ExpressionStatement{Token: exp.Token, Expression: node})
+			}
+		}
+	}
+
+	return spliced, nil
+}
+
+func evalUnquoteSplice(env *object.Environment, call *ast.CallExpression) ([]ast.Node, *object.Error) {
+	if len(call.Arguments) != 1 {
+		return nil, newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(call.Arguments))
+	}
+
+	evaluated := Eval(env, call.Arguments[0])
+	if isError(evaluated) {
+		return nil, evaluated.(*object.Error)
+	}
+
+	array, ok := evaluated.(*object.Array)
+	if !ok {
+		return nil, newError(object.TypeError, "argument to `unquote_splice` must be an ARRAY, got %s", evaluated.Type())
+	}
+
+	nodes := make([]ast.Node, 0, array.Len())
+	for _, el := range array.Elements() {
+		node := convertObjectToASTNode(el)
+		if node == nil {
+			return nil, newError(object.TypeError, "cannot splice %s into a quote", el.Type())
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
 func convertObjectToASTNode(obj object.Object) ast.Node {
 	switch obj := obj.(type) {
 	case *object.Integer:
@@ -50,6 +165,13 @@ func convertObjectToASTNode(obj object.Object) ast.Node {
 		}
 		return &ast.IntegerLiteral{Token: t, Value: obj.Value}
 
+	case *object.BigInt:
+		t := token.Token{
+			Type:    token.INT,
+			Literal: obj.Value.String(),
+		}
+		return &ast.BigIntegerLiteral{Token: t, Value: obj.Value}
+
 	case *object.Boolean:
 		var t token.Token
 		if obj.Value {
@@ -41,3 +41,41 @@ func TestQuote(t *testing.T) {
 		assert.Equal(t, tt.expected, quote.Node.String())
 	}
 }
+
+func TestQuoteUnquoteSplice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let args = [quote(1), quote(2 + 3)]; quote(add(unquote_splice(args)))`, `add(1, (2 + 3))`},
+		{`quote(add(unquote_splice([])))`, `add()`},
+		{`quote([0, unquote_splice([quote(1), 2, true]), 3])`, `[0, 1, 2, true, 3]`},
+		{`quote(fn() { unquote_splice([quote(a), quote(b)]); c })`, `fn() abc`},
+		{`quote(f(unquote(1 + 1), unquote_splice([quote(x)])))`, `f(2, x)`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testutil.TestEval(t, tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		require.Truef(t, ok, "expected *object.Quote, got %T (%+v)", evaluated, evaluated)
+		assert.Equal(t, tt.expected, quote.Node.String())
+	}
+}
+
+func TestQuoteUnquoteSpliceErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(add(unquote_splice(1)))`, "argument to `unquote_splice` must be an ARRAY, got INTEGER"},
+		{`quote([unquote_splice(["a"])])`, "cannot splice STRING into a quote"},
+		{`quote([unquote_splice(nope)])`, "identifier not found: nope"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testutil.TestEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		require.Truef(t, ok, "expected *object.Error, got %T (%+v)", evaluated, evaluated)
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
@@ -11,10 +11,10 @@ func evalProgram(env *object.Environment, program *ast.Program) object.Object {
 	for _, statement := range program.Statements {
 		result = Eval(env, statement)
 
-		switch result := result.(type) {
-		case *object.ReturnValue:
-			return result.Value
-		case *object.Error:
+		if returnValue, ok := result.(*object.ReturnValue); ok {
+			return returnValue.Value
+		}
+		if isError(result) {
 			return result
 		}
 	}
@@ -29,8 +29,7 @@ func evalBlockStatements(env *object.Environment, block *ast.BlockStatement) obj
 		result = Eval(env, statement)
 
 		if result != nil {
-			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if result.Type() == object.RETURN_VALUE_OBJ || isError(result) {
 				return result
 			}
 		}
@@ -45,7 +44,7 @@ func evalIfStatement(env *object.Environment, ie *ast.IfExpression) object.Objec
 		return condition
 	}
 
-	if isTruthy(condition) {
+	if object.IsTruthy(condition) {
 		return Eval(env, ie.Consequence)
 	} else if ie.Alternative != nil {
 		return Eval(env, ie.Alternative)
@@ -53,3 +52,30 @@ func evalIfStatement(env *object.Environment, ie *ast.IfExpression) object.Objec
 		return NULL
 	}
 }
+
+func evalForExpression(env *object.Environment, fe *ast.ForExpression) object.Object {
+	iterable := Eval(env, fe.Iterable)
+	if isError(iterable) {
+		return iterable
+	}
+	if err := raiseOperand(iterable); err != nil {
+		return err
+	}
+
+	iterator, ok := object.Iterate(iterable)
+	if !ok {
+		return withPos(newError(object.TypeError, "cannot iterate over %s", iterable.Type()), fe.Token.Pos)
+	}
+
+	for value, ok := iterator.Next(); ok; value, ok = iterator.Next() {
+		// Like in the VM, the variable keeps its last value after the loop
+		env.Set(fe.Variable.Value, value)
+
+		result := Eval(object.NewLoopEnvironment(env, fe.Variable.Value, value), fe.Body)
+		if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || isError(result)) {
+			return result
+		}
+	}
+
+	return NULL
+}
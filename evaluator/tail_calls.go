@@ -0,0 +1,110 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+const TAIL_CALL_OBJ object.ObjectType = "TAIL_CALL"
+
+// A tailCall is what a call in tail position evaluates to. Instead of calling
+// the function right away, it is handed back to applyFunction which runs it
+// in a loop, so tail-recursive functions don't grow the Go stack.
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+	call *ast.CallExpression
+}
+
+func (tc *tailCall) Type() object.ObjectType {
+	return TAIL_CALL_OBJ
+}
+
+func (tc *tailCall) Inspect() string {
+	return "tail call to " + tc.fn.Inspect()
+}
+
+// evalFunctionBody evaluates the statements of a function body. Calls in
+// tail position, which are the operand of a `return` and the last
+// expression of the body, including through `if` branches, are returned as
+// a *tailCall.
+func evalFunctionBody(env *object.Environment, body *ast.BlockStatement) object.Object {
+	return evalTailBlock(env, body, true)
+}
+
+// evalTailBlock evaluates a block of a function body. tail reports whether
+// the value of the block is the return value of the function.
+func evalTailBlock(env *object.Environment, block *ast.BlockStatement, tail bool) object.Object {
+	var result object.Object
+
+	for i, statement := range block.Statements {
+		isLast := tail && i == len(block.Statements)-1
+
+		switch statement := statement.(type) {
+		case *ast.ReturnStatement:
+			result = evalTailExpression(env, statement.ReturnValue, true)
+			if result != nil && result.Type() != TAIL_CALL_OBJ && !isError(result) {
+				result = &object.ReturnValue{Value: result}
+			}
+		case *ast.ExpressionStatement:
+			result = evalTailExpression(env, statement.Expression, isLast)
+		default:
+			result = Eval(env, statement)
+		}
+
+		if result != nil {
+			rt := result.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == TAIL_CALL_OBJ || isError(result) {
+				return result
+			}
+		}
+	}
+
+	return result
+}
+
+func evalTailExpression(env *object.Environment, exp ast.Expression, tail bool) object.Object {
+	switch exp := exp.(type) {
+	case *ast.IfExpression:
+		return evalTailIfExpression(env, exp, tail)
+	case *ast.CallExpression:
+		if !tail || isSpecialForm(exp) {
+			return Eval(env, exp)
+		}
+
+		function := Eval(env, exp.Function)
+		if isError(function) {
+			return function
+		}
+
+		args := evalExpressions(env, exp.Arguments)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+
+		// Only calls of Monkey functions grow the stack, builtins are called
+		// right away so they show up in tracebacks like in the VM
+		if _, ok := function.(*object.Function); !ok {
+			return applyFunction(env.Runtime(), function, args, exp)
+		}
+
+		return &tailCall{fn: function, args: args, call: exp}
+	default:
+		return Eval(env, exp)
+	}
+}
+
+func evalTailIfExpression(env *object.Environment, ie *ast.IfExpression, tail bool) object.Object {
+	condition := Eval(env, ie.Condition)
+	if isError(condition) {
+		return condition
+	}
+
+	if object.IsTruthy(condition) {
+		return evalTailBlock(env, ie.Consequence, tail)
+	} else if ie.Alternative != nil {
+		return evalTailBlock(env, ie.Alternative, tail)
+	} else {
+		return NULL
+	}
+}
@@ -9,17 +9,26 @@ type Lexer struct {
 	position     int  // Current position in input (points to current char)
 	readPosition int  // Current reading position in input (after current char)
 	char         byte // Current character under examination
+	line         int  // Line of the current character
+	column       int  // Column of the current character
 }
 
 func New(input string) *Lexer {
 	lex := &Lexer{
 		input: input,
+		line:  1,
 	}
 	lex.readChar()
 	return lex
 }
 
 func (l *Lexer) readChar() {
+	if l.char == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.readPosition >= len(l.input) {
 		l.char = 0
 	} else {
@@ -33,6 +42,7 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	pos := token.Position{Line: l.line, Column: l.column}
 
 	switch l.char {
 	case '=':
@@ -93,10 +103,12 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.char) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos = pos
 			return tok
 		} else if isDigit(l.char) {
 			tok.Type = token.INT
 			tok.Literal = l.readNumber()
+			tok.Pos = pos
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.char)
@@ -104,6 +116,7 @@ func (l *Lexer) NextToken() token.Token {
 	}
 
 	l.readChar()
+	tok.Pos = pos
 	return tok
 }
 
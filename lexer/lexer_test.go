@@ -34,6 +34,7 @@ if (5 < 10) {
 [1, 2];
 {"foo": "bar"};
 macro(x, y) { x + y; };
+for (x in y) {}
 `
 
 	tests := []struct {
@@ -144,6 +145,14 @@ macro(x, y) { x + y; };
 		{token.SEMICOLON, ";"},
 		{token.RBRACE, "}"},
 		{token.SEMICOLON, ";"},
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.IN, "in"},
+		{token.IDENT, "y"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
 		{token.EOF, ""},
 	}
 
@@ -154,3 +163,31 @@ macro(x, y) { x + y; };
 		assert.Equal(t, tt.expectedLiteral, tok.Literal, "Token.Literal does not match")
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `let five = 5;
+  five + "ten";`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+	}{
+		{token.LET, token.Position{Line: 1, Column: 1}},
+		{token.IDENT, token.Position{Line: 1, Column: 5}},
+		{token.ASSIGN, token.Position{Line: 1, Column: 10}},
+		{token.INT, token.Position{Line: 1, Column: 12}},
+		{token.SEMICOLON, token.Position{Line: 1, Column: 13}},
+		{token.IDENT, token.Position{Line: 2, Column: 3}},
+		{token.PLUS, token.Position{Line: 2, Column: 8}},
+		{token.STRING, token.Position{Line: 2, Column: 10}},
+		{token.SEMICOLON, token.Position{Line: 2, Column: 15}},
+		{token.EOF, token.Position{Line: 2, Column: 16}},
+	}
+
+	lex := lexer.New(input)
+	for _, tt := range tests {
+		tok := lex.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type, "Token.Type does not match")
+		assert.Equal(t, tt.expectedPos, tok.Pos, "Token.Pos does not match for %q", tok.Literal)
+	}
+}
@@ -0,0 +1,109 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+var (
+	ErrDivisionByZero  = errors.New("division by zero")
+	ErrIntegerOverflow = errors.New("integer overflow")
+)
+
+// ArithmeticMode decides what integer arithmetic does when a result doesn't
+// fit in an int64.
+type ArithmeticMode int
+
+const (
+	// PromotingArithmetic returns a BigInt on overflow.
+	PromotingArithmetic ArithmeticMode = iota
+	// WrappingArithmetic wraps around on overflow, like Go does.
+	WrappingArithmetic
+	// CheckedArithmetic reports ErrIntegerOverflow on overflow.
+	CheckedArithmetic
+)
+
+// Infix applies one of the operators + - * / to two objects for which
+// IsInteger is true. Dividing by zero reports ErrDivisionByZero in every mode.
+func (m ArithmeticMode) Infix(operator string, left, right Object) (Object, error) {
+	a, aok := left.(*Integer)
+	b, bok := right.(*Integer)
+	if aok && bok {
+		result, ok, err := int64Infix(operator, a.Value, b.Value)
+		if err != nil {
+			return nil, err
+		}
+		if ok || m == WrappingArithmetic {
+			return &Integer{Value: result}, nil
+		}
+		if m == CheckedArithmetic {
+			return nil, ErrIntegerOverflow
+		}
+	}
+
+	return bigInfix(operator, toBig(left), toBig(right))
+}
+
+// Negate returns -operand for an object for which IsInteger is true.
+func (m ArithmeticMode) Negate(operand Object) (Object, error) {
+	if integer, ok := operand.(*Integer); ok {
+		if integer.Value != math.MinInt64 || m == WrappingArithmetic {
+			return &Integer{Value: -integer.Value}, nil
+		}
+		if m == CheckedArithmetic {
+			return nil, ErrIntegerOverflow
+		}
+	}
+
+	return NewInteger(new(big.Int).Neg(toBig(operand))), nil
+}
+
+// int64Infix returns the wrapped result of the operation and whether it
+// didn't overflow.
+func int64Infix(operator string, a, b int64) (int64, bool, error) {
+	switch operator {
+	case "+":
+		result := a + b
+		return result, (result > a) == (b > 0), nil
+	case "-":
+		result := a - b
+		return result, (result < a) == (b > 0), nil
+	case "*":
+		if a == 0 || b == 0 {
+			return 0, true, nil
+		}
+		result := a * b
+		return result, result/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64), nil
+	case "/":
+		if b == 0 {
+			return 0, false, ErrDivisionByZero
+		}
+		return a / b, !(a == math.MinInt64 && b == -1), nil
+	default:
+		return 0, false, fmt.Errorf("unknown integer operator: %s", operator)
+	}
+}
+
+func bigInfix(operator string, a, b *big.Int) (Object, error) {
+	result := new(big.Int)
+
+	switch operator {
+	case "+":
+		result.Add(a, b)
+	case "-":
+		result.Sub(a, b)
+	case "*":
+		result.Mul(a, b)
+	case "/":
+		if b.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+		result.Quo(a, b)
+	default:
+		return nil, fmt.Errorf("unknown integer operator: %s", operator)
+	}
+
+	return NewInteger(result), nil
+}
@@ -2,11 +2,19 @@ package object
 
 import (
 	"bytes"
+	"hash/fnv"
 	"strings"
 )
 
+// Array is an immutable list of objects. It is backed by a persistent
+// vector, so Push and Rest return a new Array that shares its elements with
+// the original instead of copying them.
 type Array struct {
-	Elements []Object
+	elements vector
+}
+
+func NewArray(elements []Object) *Array {
+	return &Array{elements: newVector(elements)}
 }
 
 func (a *Array) Type() ObjectType {
@@ -16,8 +24,8 @@ func (a *Array) Type() ObjectType {
 func (a *Array) Inspect() string {
 	var out bytes.Buffer
 
-	elements := make([]string, 0, len(a.Elements))
-	for _, el := range a.Elements {
+	elements := make([]string, 0, a.Len())
+	for _, el := range a.Elements() {
 		elements = append(elements, el.Inspect())
 	}
 
@@ -27,3 +35,38 @@ func (a *Array) Inspect() string {
 
 	return out.String()
 }
+
+// HashKey combines the keys of the elements in order. Use HashKeyOf to check
+// that all elements are hashable first.
+func (a *Array) HashKey() HashKey {
+	h := fnv.New64a()
+	for _, el := range a.Elements() {
+		writeHashKey(h, el)
+	}
+
+	return HashKey{Type: a.Type(), Value: h.Sum64()}
+}
+
+func (a *Array) Len() int {
+	return a.elements.len()
+}
+
+// At returns the element at index i, which must be in range.
+func (a *Array) At(i int) Object {
+	return a.elements.at(i)
+}
+
+// Elements returns a copy of the elements.
+func (a *Array) Elements() []Object {
+	return a.elements.slice()
+}
+
+// Push returns a new Array with obj added to the end.
+func (a *Array) Push(obj Object) *Array {
+	return &Array{elements: a.elements.push(obj)}
+}
+
+// Rest returns a new Array without the first element. a must not be empty.
+func (a *Array) Rest() *Array {
+	return &Array{elements: a.elements.rest()}
+}
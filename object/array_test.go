@@ -0,0 +1,85 @@
+package object_test
+
+import (
+	"monkey/object"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func integers(n int) []object.Object {
+	elements := make([]object.Object, n)
+	for i := range elements {
+		elements[i] = &object.Integer{Value: int64(i)}
+	}
+
+	return elements
+}
+
+func TestArrayPush(t *testing.T) {
+	// Large enough to need three levels of trie nodes below the tail.
+	const size = 40000
+
+	arr := &object.Array{}
+	versions := map[int]*object.Array{}
+	for i, el := range integers(size) {
+		if i == 31 || i == 32 || i == 1024 || i == 1056 {
+			versions[i] = arr
+		}
+		arr = arr.Push(el)
+	}
+
+	require.Equal(t, size, arr.Len())
+	for i := 0; i < size; i++ {
+		assert.Equal(t, int64(i), arr.At(i).(*object.Integer).Value)
+	}
+
+	for length, version := range versions {
+		assert.Equal(t, length, version.Len(), "pushing changed an earlier array")
+		if length > 0 {
+			assert.Equal(t, int64(length-1), version.At(length-1).(*object.Integer).Value)
+		}
+	}
+}
+
+func TestArrayRest(t *testing.T) {
+	arr := object.NewArray(integers(100))
+
+	rest := arr
+	for i := 0; i < 60; i++ {
+		rest = rest.Rest()
+	}
+
+	assert.Equal(t, 100, arr.Len())
+	require.Equal(t, 40, rest.Len())
+	assert.Equal(t, int64(60), rest.At(0).(*object.Integer).Value)
+	assert.Equal(t, int64(99), rest.At(39).(*object.Integer).Value)
+
+	pushed := rest.Push(&object.Integer{Value: 100})
+	assert.Equal(t, 41, pushed.Len())
+	assert.Equal(t, int64(100), pushed.At(40).(*object.Integer).Value)
+	assert.Equal(t, 40, rest.Len())
+}
+
+func BenchmarkArrayPush(b *testing.B) {
+	elements := integers(10000)
+
+	for i := 0; i < b.N; i++ {
+		arr := &object.Array{}
+		for _, el := range elements {
+			arr = arr.Push(el)
+		}
+	}
+}
+
+func BenchmarkArrayRest(b *testing.B) {
+	arr := object.NewArray(integers(10000))
+
+	for i := 0; i < b.N; i++ {
+		rest := arr
+		for rest.Len() > 0 {
+			rest = rest.Rest()
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package object
+
+import (
+	"hash/fnv"
+	"math/big"
+)
+
+// BigInt is an integer that doesn't fit in an int64. Arithmetic always
+// returns an Integer when the result fits, see NewInteger.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Type() ObjectType {
+	return BIG_INT_OBJ
+}
+
+func (b *BigInt) Inspect() string {
+	return b.Value.String()
+}
+
+// HashKey returns the same key as an Integer with the same value, so both
+// can be used to look up the same hash pair.
+func (b *BigInt) HashKey() HashKey {
+	if b.Value.IsInt64() {
+		return (&Integer{Value: b.Value.Int64()}).HashKey()
+	}
+
+	h := fnv.New64a()
+	if b.Value.Sign() < 0 {
+		h.Write([]byte{'-'})
+	}
+	h.Write(b.Value.Bytes())
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+// NewInteger returns value as an Integer if it fits in an int64 and as a
+// BigInt otherwise.
+func NewInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+
+	return &BigInt{Value: value}
+}
+
+// IsInteger reports whether obj is an Integer or a BigInt.
+func IsInteger(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *BigInt:
+		return true
+	default:
+		return false
+	}
+}
+
+// CompareIntegers compares two objects for which IsInteger is true and
+// returns -1, 0 or +1 like big.Int.Cmp.
+func CompareIntegers(left, right Object) int {
+	a, aok := left.(*Integer)
+	b, bok := right.(*Integer)
+	if aok && bok {
+		switch {
+		case a.Value < b.Value:
+			return -1
+		case a.Value > b.Value:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return toBig(left).Cmp(toBig(right))
+}
+
+func toBig(obj Object) *big.Int {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value)
+	case *BigInt:
+		return obj.Value
+	default:
+		return nil
+	}
+}
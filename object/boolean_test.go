@@ -17,3 +17,23 @@ func TestBooleanHashKey(t *testing.T) {
 	assert.EqualValuesf(t, false1.HashKey(), false2.HashKey(), "booleans with the same content have different hash keys")
 	assert.NotEqualValuesf(t, true1.HashKey(), false1.HashKey(), "booleans with the different content have the same hash keys")
 }
+
+func TestIsTruthy(t *testing.T) {
+	tests := []struct {
+		obj      object.Object
+		expected bool
+	}{
+		{object.TRUE, true},
+		{object.FALSE, false},
+		{&object.Boolean{Value: false}, false},
+		{object.NULL, false},
+		{nil, false},
+		{&object.Integer{Value: 0}, true},
+		{&object.String{Value: ""}, true},
+		{object.NewArray(nil), true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, object.IsTruthy(tt.obj), "%v", tt.obj)
+	}
+}
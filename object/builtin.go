@@ -1,6 +1,17 @@
 package object
 
-type BuiltinFunction func(args ...Object) Object
+// An Engine runs Monkey code. Builtins get the engine that calls them, so
+// they can call back into Monkey functions passed to them.
+type Engine interface {
+	// Call calls fn with args and returns its result. If the call fails, the
+	// result is a raised *Error, which the builtin should return right away.
+	Call(fn Object, args ...Object) Object
+
+	// Runtime returns the runtime of the running program.
+	Runtime() *Runtime
+}
+
+type BuiltinFunction func(engine Engine, args ...Object) Object
 
 type Builtin struct {
 	Fn BuiltinFunction
@@ -1,6 +1,14 @@
 package object
 
-import "fmt"
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
 
 func GetBuiltinByName(name string) *Builtin {
 	for _, def := range Builtins {
@@ -12,31 +20,48 @@ func GetBuiltinByName(name string) *Builtin {
 	return nil
 }
 
+// BuiltinName returns the name b is defined under in Builtins.
+func BuiltinName(b *Builtin) string {
+	for _, def := range Builtins {
+		if def.Builtin == b {
+			return def.Name
+		}
+	}
+
+	return ""
+}
+
 var Builtins = []struct {
 	Name    string
 	Builtin *Builtin
 }{
 	{
 		"len",
-		&Builtin{Fn: func(args ...Object) Object {
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 			switch arg := args[0].(type) {
 			case *Array:
-				return &Integer{Value: int64(len(arg.Elements))}
+				return &Integer{Value: int64(arg.Len())}
+			// Strings are measured in bytes. The indexes `index_of` returns
+			// and `substr` takes are byte offsets too, and `substr` rejects
+			// offsets inside a character. `chars` splits a string into its
+			// characters.
 			case *String:
 				return &Integer{Value: int64(len(arg.Value))}
+			case *Range:
+				return &Integer{Value: arg.Len()}
 			default:
-				return newError("argument to `len` not supported, got %s", args[0].Type())
+				return newError(TypeError, "argument to `len` not supported, got %s", args[0].Type())
 			}
 		}},
 	},
 	{
 		"puts",
-		&Builtin{Fn: func(args ...Object) Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			if err := writeLines(engine.Runtime().IO().Stdout, args); err != nil {
+				return newError(IOError, "could not write to stdout: %s", err)
 			}
 
 			return nil
@@ -44,17 +69,17 @@ var Builtins = []struct {
 	},
 	{
 		"first",
-		&Builtin{Fn: func(args ...Object) Object {
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 			if args[0].Type() != ARRAY_OBJ {
-				return newError("argument to `first` must be an ARRAY, got %s", args[0].Type())
+				return newError(TypeError, "argument to `first` must be an ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*Array)
-			if len(arr.Elements) > 0 {
-				return arr.Elements[0]
+			if arr.Len() > 0 {
+				return arr.At(0)
 			}
 
 			return nil
@@ -62,18 +87,18 @@ var Builtins = []struct {
 	},
 	{
 		"last",
-		&Builtin{Fn: func(args ...Object) Object {
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 			if args[0].Type() != ARRAY_OBJ {
-				return newError("argument to `last` must be an ARRAY, got %s", args[0].Type())
+				return newError(TypeError, "argument to `last` must be an ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*Array)
-			length := len(arr.Elements)
-			if len(arr.Elements) > 0 {
-				return arr.Elements[length-1]
+			length := arr.Len()
+			if length > 0 {
+				return arr.At(length - 1)
 			}
 
 			return nil
@@ -81,20 +106,17 @@ var Builtins = []struct {
 	},
 	{
 		"rest",
-		&Builtin{Fn: func(args ...Object) Object {
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 			if args[0].Type() != ARRAY_OBJ {
-				return newError("argument to `rest` must be an ARRAY, got %s", args[0].Type())
+				return newError(TypeError, "argument to `rest` must be an ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*Array)
-			length := len(arr.Elements)
-			if len(arr.Elements) > 0 {
-				newElements := make([]Object, length-1)
-				copy(newElements, arr.Elements[1:length])
-				return &Array{Elements: newElements}
+			if arr.Len() > 0 {
+				return arr.Rest()
 			}
 
 			return nil
@@ -102,21 +124,937 @@ var Builtins = []struct {
 	},
 	{
 		"push",
-		&Builtin{Fn: func(args ...Object) Object {
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
 			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
 			}
 			if args[0].Type() != ARRAY_OBJ {
-				return newError("argument to `push` must be an ARRAY, got %s", args[0].Type())
+				return newError(TypeError, "argument to `push` must be an ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*Array)
-			length := len(arr.Elements)
-			newElements := make([]Object, length+1)
-			copy(newElements, arr.Elements)
-			newElements[length] = args[1]
+			return arr.Push(args[1])
+		}},
+	},
+	{
+		"set",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 3 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=3", len(args))
+			}
+			if args[0].Type() != HASH_OBJ {
+				return newError(TypeError, "argument to `set` must be a HASH, got %s", args[0].Type())
+			}
+
+			hash, ok := args[0].(*Hash).With(args[1], args[2])
+			if !ok {
+				return newError(TypeError, "unusable as hash key: %s", args[1].Type())
+			}
+
+			return hash
+		}},
+	},
+	{
+		"error",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2 or 3", len(args))
+			}
+
+			kind, ok := args[0].(*String)
+			if !ok {
+				return newError(TypeError, "argument to `error` must be a STRING, got %s", args[0].Type())
+			}
+			message, ok := args[1].(*String)
+			if !ok {
+				return newError(TypeError, "argument to `error` must be a STRING, got %s", args[1].Type())
+			}
+
+			err := &Error{Kind: kind.Value, Message: message.Value}
+			if len(args) == 3 {
+				cause, ok := args[2].(*Error)
+				if !ok {
+					return newError(TypeError, "cause passed to `error` must be an ERROR, got %s", args[2].Type())
+				}
+				err.Cause = cause
+			}
+
+			return err
+		}},
+	},
+	{
+		"is_error",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			return nativeBoolToBooleanObject(args[0].Type() == ERROR_OBJ)
+		}},
+	},
+	{
+		"error_kind",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			err, ok := args[0].(*Error)
+			if !ok {
+				return newError(TypeError, "argument to `error_kind` must be an ERROR, got %s", args[0].Type())
+			}
+
+			return &String{Value: err.Kind}
+		}},
+	},
+	{
+		"error_message",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			err, ok := args[0].(*Error)
+			if !ok {
+				return newError(TypeError, "argument to `error_message` must be an ERROR, got %s", args[0].Type())
+			}
+
+			return &String{Value: err.Message}
+		}},
+	},
+	{
+		"map",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			arr, fn, err := arrayAndCallback("map", args)
+			if err != nil {
+				return err
+			}
+
+			result := make([]Object, arr.Len())
+			for i := range result {
+				value := engine.Call(fn, arr.At(i))
+				if isRaised(value) {
+					return value
+				}
+				result[i] = orNull(value)
+			}
+
+			return NewArray(result)
+		}},
+	},
+	{
+		"filter",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			arr, fn, err := arrayAndCallback("filter", args)
+			if err != nil {
+				return err
+			}
+
+			result := []Object{}
+			for i := 0; i < arr.Len(); i++ {
+				keep := engine.Call(fn, arr.At(i))
+				if isRaised(keep) {
+					return keep
+				}
+				if IsTruthy(keep) {
+					result = append(result, arr.At(i))
+				}
+			}
+
+			return NewArray(result)
+		}},
+	},
+	{
+		"reduce",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			if len(args) != 3 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=3", len(args))
+			}
+			arr, fn, err := arrayAndCallback("reduce", []Object{args[0], args[2]})
+			if err != nil {
+				return err
+			}
+
+			acc := args[1]
+			for i := 0; i < arr.Len(); i++ {
+				acc = engine.Call(fn, acc, arr.At(i))
+				if isRaised(acc) {
+					return acc
+				}
+				acc = orNull(acc)
+			}
+
+			return acc
+		}},
+	},
+	{
+		"each",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			arr, fn, err := arrayAndCallback("each", args)
+			if err != nil {
+				return err
+			}
+
+			for i := 0; i < arr.Len(); i++ {
+				if result := engine.Call(fn, arr.At(i)); isRaised(result) {
+					return result
+				}
+			}
+
+			return nil
+		}},
+	},
+	{
+		"sort_by",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			arr, fn, err := arrayAndCallback("sort_by", args)
+			if err != nil {
+				return err
+			}
+
+			elements := arr.Elements()
+			keys := make([]Object, len(elements))
+			for i, element := range elements {
+				key := engine.Call(fn, element)
+				if isRaised(key) {
+					return key
+				}
+				keys[i] = orNull(key)
+			}
+
+			return sortByKeys(elements, keys)
+		}},
+	},
+	{
+		"split",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("split", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return stringArray(strings.Split(strs[0], strs[1]))
+		}},
+	},
+	{
+		"join",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 2 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			arr, ok := args[0].(*Array)
+			if !ok {
+				return newError(TypeError, "argument to `join` must be an ARRAY, got %s", args[0].Type())
+			}
+			sep, ok := args[1].(*String)
+			if !ok {
+				return newError(TypeError, "argument to `join` must be a STRING, got %s", args[1].Type())
+			}
+
+			parts := make([]string, arr.Len())
+			for i := range parts {
+				str, ok := arr.At(i).(*String)
+				if !ok {
+					return newError(TypeError, "elements passed to `join` must be STRINGs, got %s", arr.At(i).Type())
+				}
+				parts[i] = str.Value
+			}
+
+			return &String{Value: strings.Join(parts, sep.Value)}
+		}},
+	},
+	{
+		"trim",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("trim", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &String{Value: strings.TrimSpace(strs[0])}
+		}},
+	},
+	{
+		"upper",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("upper", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &String{Value: strings.ToUpper(strs[0])}
+		}},
+	},
+	{
+		"lower",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("lower", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &String{Value: strings.ToLower(strs[0])}
+		}},
+	},
+	{
+		"contains",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("contains", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(strings.Contains(strs[0], strs[1]))
+		}},
+	},
+	{
+		"starts_with",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("starts_with", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(strings.HasPrefix(strs[0], strs[1]))
+		}},
+	},
+	{
+		"ends_with",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("ends_with", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(strings.HasSuffix(strs[0], strs[1]))
+		}},
+	},
+	{
+		"replace",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("replace", args, 3)
+			if err != nil {
+				return err
+			}
+
+			return &String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}
+		}},
+	},
+	{
+		"index_of",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("index_of", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return &Integer{Value: int64(strings.Index(strs[0], strs[1]))}
+		}},
+	},
+	{
+		"substr",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2 or 3", len(args))
+			}
+			str, ok := args[0].(*String)
+			if !ok {
+				return newError(TypeError, "argument to `substr` must be a STRING, got %s", args[0].Type())
+			}
+			start, err := intArg("substr", args[1])
+			if err != nil {
+				return err
+			}
+			if start < 0 || start > int64(len(str.Value)) {
+				return newError(ArgumentError, "start index %d out of range for string of length %d", start, len(str.Value))
+			}
+
+			if !isCharBoundary(str.Value, start) {
+				return newError(ArgumentError, "start index %d passed to `substr` is inside a character", start)
+			}
+
+			end := int64(len(str.Value))
+			if len(args) == 3 {
+				length, err := intArg("substr", args[2])
+				if err != nil {
+					return err
+				}
+				if length < 0 {
+					return newError(ArgumentError, "length passed to `substr` must not be negative, got %d", length)
+				}
+				end = min(end, start+min(length, end))
+				if !isCharBoundary(str.Value, end) {
+					return newError(ArgumentError, "length %d passed to `substr` ends inside a character", length)
+				}
+			}
+
+			return &String{Value: str.Value[start:end]}
+		}},
+	},
+	{
+		"repeat",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 2 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			str, ok := args[0].(*String)
+			if !ok {
+				return newError(TypeError, "argument to `repeat` must be a STRING, got %s", args[0].Type())
+			}
+			count, err := intArg("repeat", args[1])
+			if err != nil {
+				return err
+			}
+			if count < 0 {
+				return newError(ArgumentError, "count passed to `repeat` must not be negative, got %d", count)
+			}
+			if len(str.Value) > 0 && count > maxStringLength/int64(len(str.Value)) {
+				return newError(ArgumentError, "result of `repeat` would be longer than %d bytes", maxStringLength)
+			}
+
+			return &String{Value: strings.Repeat(str.Value, int(count))}
+		}},
+	},
+	{
+		"chars",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("chars", args, 1)
+			if err != nil {
+				return err
+			}
+
+			chars := []string{}
+			for _, r := range strs[0] {
+				chars = append(chars, string(r))
+			}
+
+			return stringArray(chars)
+		}},
+	},
+	{
+		"keys",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			hash, err := hashArg("keys", args[0])
+			if err != nil {
+				return err
+			}
+
+			pairs := hash.SortedPairs()
+			elements := make([]Object, len(pairs))
+			for i, pair := range pairs {
+				elements[i] = pair.Key
+			}
+
+			return NewArray(elements)
+		}},
+	},
+	{
+		"values",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			hash, err := hashArg("values", args[0])
+			if err != nil {
+				return err
+			}
+
+			pairs := hash.SortedPairs()
+			elements := make([]Object, len(pairs))
+			for i, pair := range pairs {
+				elements[i] = pair.Value
+			}
+
+			return NewArray(elements)
+		}},
+	},
+	{
+		"entries",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			hash, err := hashArg("entries", args[0])
+			if err != nil {
+				return err
+			}
+
+			pairs := hash.SortedPairs()
+			elements := make([]Object, len(pairs))
+			for i, pair := range pairs {
+				elements[i] = NewArray([]Object{pair.Key, pair.Value})
+			}
+
+			return NewArray(elements)
+		}},
+	},
+	{
+		"has",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 2 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			hash, err := hashArg("has", args[0])
+			if err != nil {
+				return err
+			}
+			if _, ok := HashKeyOf(args[1]); !ok {
+				return newError(TypeError, "unusable as hash key: %s", args[1].Type())
+			}
+
+			_, ok := hash.Get(args[1])
+			return nativeBoolToBooleanObject(ok)
+		}},
+	},
+	{
+		"get",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2 or 3", len(args))
+			}
+			hash, err := hashArg("get", args[0])
+			if err != nil {
+				return err
+			}
+			if _, ok := HashKeyOf(args[1]); !ok {
+				return newError(TypeError, "unusable as hash key: %s", args[1].Type())
+			}
+
+			if value, ok := hash.Get(args[1]); ok {
+				return value
+			}
+			if len(args) == 3 {
+				return args[2]
+			}
+
+			return nil
+		}},
+	},
+	{
+		"delete",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 2 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			hash, err := hashArg("delete", args[0])
+			if err != nil {
+				return err
+			}
+
+			deleted, ok := hash.Without(args[1])
+			if !ok {
+				return newError(TypeError, "unusable as hash key: %s", args[1].Type())
+			}
+
+			return deleted
+		}},
+	},
+	{
+		"merge",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 2 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			merged, err := hashArg("merge", args[0])
+			if err != nil {
+				return err
+			}
+			other, err := hashArg("merge", args[1])
+			if err != nil {
+				return err
+			}
+
+			for _, pair := range other.Pairs() {
+				merged, _ = merged.With(pair.Key, pair.Value)
+			}
+
+			return merged
+		}},
+	},
+	{
+		"type",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			return &String{Value: string(typeName(args[0]))}
+		}},
+	},
+	{
+		"int",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *Integer, *BigInt:
+				return arg
+			case *Boolean:
+				if arg.Value {
+					return &Integer{Value: 1}
+				}
+				return &Integer{Value: 0}
+			case *String:
+				return parseInteger(arg.Value)
+			default:
+				return newError(TypeError, "argument to `int` not supported, got %s", typeName(arg))
+			}
+		}},
+	},
+	{
+		"str",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if str, ok := args[0].(*String); ok {
+				return str
+			}
+
+			return &String{Value: args[0].Inspect()}
+		}},
+	},
+	{
+		"bool",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			return nativeBoolToBooleanObject(IsTruthy(args[0]))
+		}},
+	},
+	{
+		"json_parse",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			strs, err := stringArgs("json_parse", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return parseJSON(strs[0])
+		}},
+	},
+	{
+		"json_stringify",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			var indent int64
+			if len(args) == 2 {
+				var err *Error
+				if indent, err = intArg("json_stringify", args[1]); err != nil {
+					return err
+				}
+				if indent < 0 || indent > 16 {
+					return newError(ArgumentError, "indent passed to `json_stringify` must be between 0 and 16, got %d", indent)
+				}
+			}
+
+			return stringifyJSON(args[0], int(indent))
+		}},
+	},
+	{
+		"print",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			for _, arg := range args {
+				if _, err := io.WriteString(engine.Runtime().IO().Stdout, arg.Inspect()); err != nil {
+					return newError(IOError, "could not write to stdout: %s", err)
+				}
+			}
+
+			return nil
+		}},
+	},
+	{
+		"eprint",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			if err := writeLines(engine.Runtime().IO().Stderr, args); err != nil {
+				return newError(IOError, "could not write to stderr: %s", err)
+			}
+
+			return nil
+		}},
+	},
+	{
+		"read_line",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			if len(args) != 0 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			line, ok, err := engine.Runtime().ReadLine()
+			if err != nil {
+				return newError(IOError, "could not read from stdin: %s", err)
+			}
+			if !ok {
+				return nil
+			}
+
+			return &String{Value: line}
+		}},
+	},
+	{
+		"read_file",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			root, path, err := fileArgs(engine, "read_file", args, 1, false)
+			if err != nil {
+				return err
+			}
+			defer root.Close()
+
+			content, readErr := root.ReadFile(path)
+			if readErr != nil {
+				return fileError("read file", readErr)
+			}
+
+			return &String{Value: string(content)}
+		}},
+	},
+	{
+		"write_file",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			root, path, err := fileArgs(engine, "write_file", args, 2, true)
+			if err != nil {
+				return err
+			}
+			defer root.Close()
+
+			content, ok := args[1].(*String)
+			if !ok {
+				return newError(TypeError, "argument to `write_file` must be a STRING, got %s", args[1].Type())
+			}
+
+			if writeErr := root.WriteFile(path, []byte(content.Value), 0o644); writeErr != nil {
+				return fileError("write file", writeErr)
+			}
+
+			return nil
+		}},
+	},
+	{
+		"list_dir",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			root, path, err := fileArgs(engine, "list_dir", args, 1, false)
+			if err != nil {
+				return err
+			}
+			defer root.Close()
+
+			dir, openErr := root.Open(path)
+			if openErr != nil {
+				return fileError("list directory", openErr)
+			}
+			defer dir.Close()
+
+			entries, readErr := dir.ReadDir(-1)
+			if readErr != nil {
+				return fileError("list directory", readErr)
+			}
+
+			names := make([]string, len(entries))
+			for i, entry := range entries {
+				names[i] = entry.Name()
+			}
+			slices.Sort(names)
+
+			return stringArray(names)
+		}},
+	},
+	{
+		"exists",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			root, path, err := fileArgs(engine, "exists", args, 1, false)
+			if err != nil {
+				return err
+			}
+			defer root.Close()
+
+			_, statErr := root.Stat(path)
+			if statErr != nil && !errors.Is(statErr, fs.ErrNotExist) {
+				return fileError("check file", statErr)
+			}
+
+			return nativeBoolToBooleanObject(statErr == nil)
+		}},
+	},
+	{
+		"remove",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			root, path, err := fileArgs(engine, "remove", args, 1, true)
+			if err != nil {
+				return err
+			}
+			defer root.Close()
+
+			if removeErr := root.Remove(path); removeErr != nil {
+				return fileError("remove file", removeErr)
+			}
+
+			return nil
+		}},
+	},
+	{
+		"now",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			if len(args) != 0 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return &Integer{Value: engine.Runtime().Clock().Now().UnixMilli()}
+		}},
+	},
+	{
+		"sleep",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			ms, err := intArg("sleep", args[0])
+			if err != nil {
+				return err
+			}
+			if ms < 0 {
+				return newError(ArgumentError, "duration passed to `sleep` must not be negative, got %d", ms)
+			}
+
+			if sleepErr := engine.Runtime().Sleep(time.Duration(ms) * time.Millisecond); sleepErr != nil {
+				return newError(RuntimeError, "%s", sleepErr)
+			}
+
+			return nil
+		}},
+	},
+	{
+		"random_int",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			if len(args) != 2 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			lo, err := intArg("random_int", args[0])
+			if err != nil {
+				return err
+			}
+			hi, err := intArg("random_int", args[1])
+			if err != nil {
+				return err
+			}
+			if lo > hi {
+				return newError(ArgumentError, "empty range passed to `random_int`: %d > %d", lo, hi)
+			}
+
+			random := engine.Runtime().Random()
+			span := uint64(hi-lo) + 1
+			if span == 0 {
+				return &Integer{Value: int64(random.Uint64())}
+			}
+
+			return &Integer{Value: lo + int64(random.Uint64N(span))}
+		}},
+	},
+	{
+		"shuffle",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			if len(args) != 1 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			arr, ok := args[0].(*Array)
+			if !ok {
+				return newError(TypeError, "argument to `shuffle` must be an ARRAY, got %s", args[0].Type())
+			}
+
+			elements := arr.Elements()
+			engine.Runtime().Random().Shuffle(len(elements), func(i, j int) {
+				elements[i], elements[j] = elements[j], elements[i]
+			})
+
+			return NewArray(elements)
+		}},
+	},
+	{
+		"range",
+		&Builtin{Fn: func(_ Engine, args ...Object) Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError(ArgumentError, "wrong number of arguments. got=%d, want=1 to 3", len(args))
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				var err *Error
+				if bounds[i], err = intArg("range", arg); err != nil {
+					return err
+				}
+			}
+
+			r := &Range{Start: 0, End: bounds[0], Step: 1}
+			switch len(bounds) {
+			case 2:
+				r = &Range{Start: bounds[0], End: bounds[1], Step: 1}
+			case 3:
+				if bounds[2] == 0 {
+					return newError(ArgumentError, "step passed to `range` must not be zero")
+				}
+				r = &Range{Start: bounds[0], End: bounds[1], Step: bounds[2]}
+			}
+			if r.count() > math.MaxInt64 {
+				return newError(ArgumentError, "range passed to `range` is too long: more than %d values", int64(math.MaxInt64))
+			}
+
+			return r
+		}},
+	},
+	{
+		"re_match",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			strs, re, err := regexpArgs(engine, "re_match", args, 2)
+			if err != nil {
+				return err
+			}
+
+			match := re.FindStringSubmatchIndex(strs[0])
+			if match == nil {
+				return NULL
+			}
+
+			return captures(re, strs[0], match)
+		}},
+	},
+	{
+		"re_find_all",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			strs, re, err := regexpArgs(engine, "re_find_all", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return findAll(re, strs[0])
+		}},
+	},
+	{
+		"re_replace",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			strs, re, err := regexpArgs(engine, "re_replace", args, 3)
+			if err != nil {
+				return err
+			}
+
+			return &String{Value: re.ReplaceAllString(strs[0], strs[2])}
+		}},
+	},
+	{
+		"re_split",
+		&Builtin{Fn: func(engine Engine, args ...Object) Object {
+			strs, re, err := regexpArgs(engine, "re_split", args, 2)
+			if err != nil {
+				return err
+			}
 
-			return &Array{Elements: newElements}
+			return stringArray(re.Split(strs[0], -1))
 		}},
 	},
 }
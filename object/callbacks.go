@@ -0,0 +1,88 @@
+package object
+
+import "sort"
+
+// arrayAndCallback checks the arguments of builtins like `map` that take an
+// array and a function to call for its elements.
+func arrayAndCallback(name string, args []Object) (*Array, Object, *Error) {
+	if len(args) != 2 {
+		return nil, nil, newError(ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+	arr, ok := args[0].(*Array)
+	if !ok {
+		return nil, nil, newError(TypeError, "argument to `%s` must be an ARRAY, got %s", name, args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return nil, nil, newError(TypeError, "argument to `%s` must be a FUNCTION, got %s", name, args[1].Type())
+	}
+
+	return arr, args[1], nil
+}
+
+func isCallable(obj Object) bool {
+	switch obj.Type() {
+	case FUNCTION_OBJ, CLOSURE_OBJ, BUILTIN_OBJ:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRaised(obj Object) bool {
+	err, ok := obj.(*Error)
+	return ok && err.Raised
+}
+
+// orNull returns NULL for the nil a function without a value returns.
+func orNull(obj Object) Object {
+	if obj == nil {
+		return NULL
+	}
+
+	return obj
+}
+
+// sortByKeys sorts elements by the matching keys, keeping the order of
+// elements with equal keys. The keys must be all integers or all strings.
+func sortByKeys(elements, keys []Object) Object {
+	var less func(a, b Object) bool
+
+	switch {
+	case allOf(keys, IsInteger):
+		less = func(a, b Object) bool { return CompareIntegers(a, b) < 0 }
+	case allOf(keys, func(obj Object) bool { return obj.Type() == STRING_OBJ }):
+		less = func(a, b Object) bool { return a.(*String).Value < b.(*String).Value }
+	default:
+		for _, key := range keys {
+			if !IsInteger(key) && key.Type() != STRING_OBJ {
+				return newError(TypeError, "sort key must be an INTEGER or a STRING, got %s", key.Type())
+			}
+		}
+		return newError(TypeError, "sort keys must all be INTEGERs or all be STRINGs")
+	}
+
+	indices := make([]int, len(elements))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return less(keys[indices[i]], keys[indices[j]])
+	})
+
+	sorted := make([]Object, len(elements))
+	for i, index := range indices {
+		sorted[i] = elements[index]
+	}
+
+	return NewArray(sorted)
+}
+
+func allOf(objs []Object, pred func(Object) bool) bool {
+	for _, obj := range objs {
+		if !pred(obj) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,104 @@
+package object
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// A Clock tells the time builtins what time it is and how to wait. Hosts
+// can inject a FixedClock to make scripts that use them reproducible.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d, or until ctx is done.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// SystemClock is the real time.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FixedClock is a clock that only moves when a script sleeps. Sleeping
+// returns right away.
+type FixedClock struct {
+	now time.Time
+}
+
+func NewFixedClock(now time.Time) *FixedClock {
+	return &FixedClock{now: now}
+}
+
+func (c *FixedClock) Now() time.Time {
+	return c.now
+}
+
+func (c *FixedClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.now = c.now.Add(d)
+	return nil
+}
+
+// NewSeededRandom returns a random number generator that always produces
+// the same numbers for the same seed.
+func NewSeededRandom(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// Clock returns the clock of the time builtins.
+func (r *Runtime) Clock() Clock {
+	return r.clock
+}
+
+// SetClock changes the clock of the time builtins. A nil clock is the
+// SystemClock. Like the arithmetic mode, the clock is kept between runs.
+func (r *Runtime) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	r.clock = clock
+}
+
+// Random returns the random number generator of the random builtins.
+func (r *Runtime) Random() *rand.Rand {
+	return r.random
+}
+
+// SetRandom changes the random number generator of the random builtins. A
+// nil generator is replaced by one with a random seed. Like the arithmetic
+// mode, the generator is kept between runs.
+func (r *Runtime) SetRandom(random *rand.Rand) {
+	if random == nil {
+		random = NewSeededRandom(rand.Uint64())
+	}
+	r.random = random
+}
+
+// Sleep waits for d on the clock. If the run is cancelled while waiting, it
+// stops the run like Step does.
+func (r *Runtime) Sleep(d time.Duration) error {
+	if err := r.clock.Sleep(r.ctx, d); err != nil {
+		r.err = fmt.Errorf("%w: %w", ErrCancelled, err)
+		return r.err
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package object_test
+
+import (
+	"context"
+	"monkey/object"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedClock(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := object.NewFixedClock(start)
+
+	assert.Equal(t, start, clock.Now())
+	assert.NoError(t, clock.Sleep(context.Background(), time.Minute))
+	assert.Equal(t, start.Add(time.Minute), clock.Now())
+}
+
+func TestRuntimeSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runtime := object.NewRuntime()
+	runtime.Begin(ctx, 0)
+	cancel()
+
+	assert.ErrorIs(t, runtime.Sleep(time.Hour), object.ErrCancelled)
+	assert.ErrorIs(t, runtime.Step(), object.ErrCancelled, "cancelling a sleep should stop the run")
+}
+
+func TestSeededRandom(t *testing.T) {
+	a := object.NewSeededRandom(42)
+	b := object.NewSeededRandom(42)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, a.Uint64(), b.Uint64())
+	}
+}
@@ -3,12 +3,15 @@ package object
 import (
 	"fmt"
 	"monkey/code"
+	"monkey/token"
 )
 
 type CompiledFunction struct {
 	Instructions  code.Instructions
 	NumLocals     int
 	NumParameters int
+	Name          string                 // The name the function was bound to with `let`, if any
+	Positions     map[int]token.Position // Source positions of the calls, by instruction offset
 }
 
 func (cf *CompiledFunction) Type() ObjectType {
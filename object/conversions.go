@@ -0,0 +1,31 @@
+package object
+
+import (
+	"math/big"
+	"strings"
+)
+
+// typeName returns the type of obj as scripts see it. Integers are INTEGER
+// whatever their size, and all functions written in Monkey are FUNCTION, so
+// both engines report the same types.
+func typeName(obj Object) ObjectType {
+	switch obj.Type() {
+	case BIG_INT_OBJ:
+		return INTEGER_OBJ
+	case CLOSURE_OBJ, COMPILED_FUNCTION_OBJ:
+		return FUNCTION_OBJ
+	default:
+		return obj.Type()
+	}
+}
+
+// parseInteger parses a decimal integer, which may be signed and surrounded
+// by whitespace.
+func parseInteger(s string) Object {
+	value, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		return newError(ArgumentError, "could not parse %q as an integer", s)
+	}
+
+	return NewInteger(value)
+}
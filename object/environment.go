@@ -1,21 +1,40 @@
 package object
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	outer   *Environment
+	runtime *Runtime
+	// binding is the only name a loop environment holds, see
+	// NewLoopEnvironment.
+	binding string
 }
 
 func NewEnvironment() *Environment {
 	store := make(map[string]Object)
-	return &Environment{store: store, outer: nil}
+	return &Environment{store: store, outer: nil, runtime: NewRuntime()}
 }
 
 func NewEnclosingEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
+	store := make(map[string]Object)
+	return &Environment{store: store, outer: outer, runtime: outer.runtime}
+}
+
+// NewLoopEnvironment returns the environment for one iteration of a loop
+// in outer that binds name to val. Every iteration gets its own binding, so
+// functions created in the loop keep the value of their iteration. Names
+// are also set in outer, so they keep their values after the loop.
+func NewLoopEnvironment(outer *Environment, name string, val Object) *Environment {
+	env := NewEnclosingEnvironment(outer)
+	env.binding = name
+	env.store[name] = val
 	return env
 }
 
+// Runtime returns the runtime shared by env and all environments enclosed by it.
+func (e *Environment) Runtime() *Runtime {
+	return e.runtime
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
 	if !ok && e.outer != nil {
@@ -25,6 +44,13 @@ func (e *Environment) Get(name string) (Object, bool) {
 }
 
 func (e *Environment) Set(name string, val Object) Object {
+	if e.binding != "" {
+		e.outer.Set(name, val)
+		if name != e.binding {
+			return val
+		}
+	}
+
 	e.store[name] = val
 	return val
 }
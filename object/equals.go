@@ -0,0 +1,60 @@
+package object
+
+// Equals reports whether a and b are structurally equal. Integers, strings,
+// booleans, null and ranges compare by value, arrays and hashes compare
+// their contents, and every other object is only equal to itself.
+func Equals(a, b Object) bool {
+	if IsInteger(a) && IsInteger(b) {
+		return CompareIntegers(a, b) == 0
+	}
+
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *Null:
+		return true
+	case *Array:
+		return arraysEqual(a, b.(*Array))
+	case *Hash:
+		return hashesEqual(a, b.(*Hash))
+	case *Range:
+		return *a == *b.(*Range)
+	default:
+		return a == b
+	}
+}
+
+func arraysEqual(a, b *Array) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	for i := 0; i < a.Len(); i++ {
+		if !Equals(a.At(i), b.At(i)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hashesEqual(a, b *Hash) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	for _, pair := range a.Pairs() {
+		other, ok := b.Get(pair.Key)
+		if !ok || !Equals(pair.Value, other) {
+			return false
+		}
+	}
+
+	return true
+}
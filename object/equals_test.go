@@ -0,0 +1,35 @@
+package object_test
+
+import (
+	"monkey/object"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEquals(t *testing.T) {
+	one := &object.Integer{Value: 1}
+	str := &object.String{Value: "a"}
+	fn := &object.Builtin{}
+
+	tests := []struct {
+		desc     string
+		a, b     object.Object
+		expected bool
+	}{
+		{"integers", &object.Integer{Value: 1}, &object.Integer{Value: 1}, true},
+		{"strings", &object.String{Value: "a"}, &object.String{Value: "a"}, true},
+		{"different types", one, str, false},
+		{"nulls", &object.Null{}, &object.Null{}, true},
+		{"nested arrays", object.NewArray([]object.Object{one, object.NewArray([]object.Object{str})}), object.NewArray([]object.Object{&object.Integer{Value: 1}, object.NewArray([]object.Object{&object.String{Value: "a"}})}), true},
+		{"same builtin", fn, fn, true},
+		{"different builtins", fn, &object.Builtin{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expected, object.Equals(tt.a, tt.b))
+			assert.Equal(t, tt.expected, object.Equals(tt.b, tt.a))
+		})
+	}
+}
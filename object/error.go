@@ -1,9 +1,32 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+	"monkey/token"
+)
+
+// The kinds of errors raised by the engines and builtins. Scripts can create
+// errors of any other kind with the `error` builtin.
+const (
+	ArgumentError   = "ArgumentError"
+	ArithmeticError = "ArithmeticError"
+	IOError         = "IOError"
+	NameError       = "NameError"
+	PermissionError = "PermissionError"
+	RuntimeError    = "RuntimeError"
+	TypeError       = "TypeError"
+)
 
 type Error struct {
+	Kind    string
 	Message string
+	Cause   *Error         // The error that led to this one, if any
+	Pos     token.Position // Where the error occurred, if known
+	Stack   Stack          // The calls that were active when the error occurred
+
+	// Raised is set for errors that abort evaluation. Errors returned by
+	// builtins are ordinary values until they are used as an operand.
+	Raised bool
 }
 
 func (e *Error) Type() ObjectType {
@@ -11,9 +34,31 @@ func (e *Error) Type() ObjectType {
 }
 
 func (e *Error) Inspect() string {
-	return "ERROR: " + e.Message
+	return "ERROR: " + e.Error()
+}
+
+// Error returns the kind and message of e and what caused it, so engines can
+// report a Monkey error as a Go error.
+func (e *Error) Error() string {
+	out := e.Message
+	if e.Kind != "" {
+		out = e.Kind + ": " + e.Message
+	}
+	if e.Cause != nil {
+		out += " (caused by " + e.Cause.Inspect() + ")"
+	}
+
+	return out
+}
+
+// Raise returns a copy of e that aborts evaluation, so raising an error value
+// doesn't change the value itself.
+func (e *Error) Raise() *Error {
+	raised := *e
+	raised.Raised = true
+	return &raised
 }
 
-func newError(format string, a ...any) *Error {
-	return &Error{Message: fmt.Sprintf(format, a...)}
+func newError(kind, format string, a ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, a...)}
 }
@@ -0,0 +1,25 @@
+package object_test
+
+import (
+	"monkey/object"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorInspect(t *testing.T) {
+	cause := &object.Error{Kind: "IOError", Message: "file not found"}
+	err := &object.Error{Kind: "ConfigError", Message: "cannot load config", Cause: cause}
+
+	assert.Equal(t, "ERROR: ConfigError: cannot load config (caused by ERROR: IOError: file not found)", err.Inspect())
+	assert.Equal(t, "ERROR: no kind", (&object.Error{Message: "no kind"}).Inspect())
+}
+
+func TestErrorRaise(t *testing.T) {
+	err := &object.Error{Kind: "ValueError", Message: "bad"}
+	raised := err.Raise()
+
+	assert.True(t, raised.Raised)
+	assert.False(t, err.Raised, "raising changed the error value")
+	assert.Equal(t, err.Message, raised.Message)
+}
@@ -0,0 +1,137 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilePolicy decides which files the file system builtins may use. Scripts
+// can only reach files below one of Roots, so a policy without roots, like
+// the zero value, disables file access entirely. ReadOnly also denies
+// writing and removing files.
+type FilePolicy struct {
+	Roots    []string
+	ReadOnly bool
+}
+
+// FilePolicy returns the policy for the file system builtins.
+func (r *Runtime) FilePolicy() FilePolicy {
+	return r.files
+}
+
+// SetFilePolicy changes the policy for the file system builtins. Like the
+// arithmetic mode, it is kept between runs.
+func (r *Runtime) SetFilePolicy(policy FilePolicy) {
+	r.files = policy
+}
+
+// check opens the root that path is below and returns it with the path
+// relative to it, or an error if the policy doesn't allow the builtin called
+// name to use path. Symbolic links are resolved first, so a link can't lead
+// outside of the roots, and the builtins go through the returned root, so
+// a link created after the check can't either. The caller must close the
+// root.
+func (p FilePolicy) check(name, path string, write bool) (*os.Root, string, *Error) {
+	if len(p.Roots) == 0 {
+		return nil, "", newError(PermissionError, "`%s` is not allowed: file access is disabled", name)
+	}
+	if write && p.ReadOnly {
+		return nil, "", newError(PermissionError, "`%s` is not allowed: file access is read-only", name)
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return nil, "", newError(IOError, "could not resolve %s: %s", path, err)
+	}
+
+	for _, root := range p.Roots {
+		resolvedRoot, err := resolvePath(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(resolvedRoot, resolved)
+		if err != nil || !isLocal(rel) {
+			continue
+		}
+
+		opened, err := os.OpenRoot(resolvedRoot)
+		if err != nil {
+			return nil, "", fileError("open "+root, err)
+		}
+		return opened, rel, nil
+	}
+
+	return nil, "", newError(PermissionError, "`%s` is not allowed: %s is outside the allowed directories", name, path)
+}
+
+// maxLinks limits how many dangling symbolic links resolvePath follows, so
+// links pointing at each other can't make it loop forever.
+const maxLinks = 255
+
+// resolvePath returns the absolute path of path with symbolic links
+// resolved. Only part of the path needs to exist, so files can be created:
+// the longest existing prefix is resolved and the rest appended to it. A
+// dangling link is replaced by its target, as writing to it would create
+// the target.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for links := 0; ; {
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		if target, err := os.Readlink(abs); err == nil {
+			if links++; links > maxLinks {
+				return "", fmt.Errorf("too many links in %s", path)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(abs), target)
+			}
+			abs = filepath.Clean(target)
+			continue
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(abs)}, missing...)
+		abs = parent
+	}
+}
+
+func isLocal(rel string) bool {
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel))
+}
+
+// fileArgs checks the arguments of a file system builtin, which start with a
+// path, and returns the root and relative path the policy allows. The caller
+// must close the root.
+func fileArgs(engine Engine, name string, args []Object, n int, write bool) (*os.Root, string, *Error) {
+	if len(args) != n {
+		return nil, "", newError(ArgumentError, "wrong number of arguments. got=%d, want=%d", len(args), n)
+	}
+	path, ok := args[0].(*String)
+	if !ok {
+		return nil, "", newError(TypeError, "argument to `%s` must be a STRING, got %s", name, args[0].Type())
+	}
+
+	return engine.Runtime().FilePolicy().check(name, path.Value, write)
+}
+
+func fileError(action string, err error) *Error {
+	return newError(IOError, "could not %s: %s", action, err)
+}
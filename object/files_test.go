@@ -0,0 +1,74 @@
+package object_test
+
+import (
+	"monkey/object"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runtimeEngine is an engine for builtins that don't call functions.
+type runtimeEngine struct{ runtime *object.Runtime }
+
+func (e runtimeEngine) Call(fn object.Object, args ...object.Object) object.Object { return nil }
+func (e runtimeEngine) Runtime() *object.Runtime                                   { return e.runtime }
+
+func callFileBuiltin(policy object.FilePolicy, name string, args ...string) object.Object {
+	runtime := object.NewRuntime()
+	runtime.SetFilePolicy(policy)
+
+	objs := make([]object.Object, len(args))
+	for i, arg := range args {
+		objs[i] = &object.String{Value: arg}
+	}
+
+	return object.GetBuiltinByName(name).Fn(runtimeEngine{runtime}, objs...)
+}
+
+func TestFilePolicy(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "in.txt"), []byte("in"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "out.txt"), []byte("out"), 0o644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "pwned"), filepath.Join(root, "dangling")))
+	require.NoError(t, os.Symlink("in-new.txt", filepath.Join(root, "dangling-inside")))
+
+	policy := object.FilePolicy{Roots: []string{root}}
+	tests := []struct {
+		policy   object.FilePolicy
+		name     string
+		args     []string
+		expected string
+	}{
+		{policy, "read_file", []string{filepath.Join(root, "in.txt")}, "in"},
+		{policy, "read_file", []string{filepath.Join(outside, "out.txt")}, "ERROR: PermissionError: `read_file` is not allowed: " + filepath.Join(outside, "out.txt") + " is outside the allowed directories"},
+		{policy, "read_file", []string{filepath.Join(root, "link", "out.txt")}, "ERROR: PermissionError: `read_file` is not allowed: " + filepath.Join(root, "link", "out.txt") + " is outside the allowed directories"},
+		{policy, "read_file", []string{filepath.Join(root, "..", filepath.Base(outside), "out.txt")}, "ERROR: PermissionError: `read_file` is not allowed: " + filepath.Join(root, "..", filepath.Base(outside), "out.txt") + " is outside the allowed directories"},
+		{policy, "write_file", []string{filepath.Join(root, "link", "new.txt"), "x"}, "ERROR: PermissionError: `write_file` is not allowed: " + filepath.Join(root, "link", "new.txt") + " is outside the allowed directories"},
+		{policy, "write_file", []string{filepath.Join(root, "dangling"), "x"}, "ERROR: PermissionError: `write_file` is not allowed: " + filepath.Join(root, "dangling") + " is outside the allowed directories"},
+		{policy, "write_file", []string{filepath.Join(root, "link", "missing", "new.txt"), "x"}, "ERROR: PermissionError: `write_file` is not allowed: " + filepath.Join(root, "link", "missing", "new.txt") + " is outside the allowed directories"},
+		{policy, "write_file", []string{filepath.Join(root, "dangling-inside"), "new"}, "null"},
+		{policy, "read_file", []string{filepath.Join(root, "in-new.txt")}, "new"},
+		{policy, "exists", []string{root}, "true"},
+		{object.FilePolicy{}, "exists", []string{root}, "ERROR: PermissionError: `exists` is not allowed: file access is disabled"},
+		{object.FilePolicy{Roots: []string{root}, ReadOnly: true}, "remove", []string{filepath.Join(root, "in.txt")}, "ERROR: PermissionError: `remove` is not allowed: file access is read-only"},
+		{object.FilePolicy{Roots: []string{root}, ReadOnly: true}, "read_file", []string{filepath.Join(root, "in.txt")}, "in"},
+	}
+
+	for _, tt := range tests {
+		result := callFileBuiltin(tt.policy, tt.name, tt.args...)
+		if result == nil {
+			result = object.NULL
+		}
+		assert.Equal(t, tt.expected, result.Inspect(), "%s(%v)", tt.name, tt.args)
+	}
+
+	for _, name := range []string{"new.txt", "pwned", "missing"} {
+		_, err := os.Stat(filepath.Join(outside, name))
+		assert.True(t, os.IsNotExist(err), "write_file followed a link out of the root to %s", name)
+	}
+}
@@ -0,0 +1,250 @@
+package object
+
+import "math/bits"
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+// hamtNode is a node of a hash array mapped trie keyed by HashKey. Each level
+// uses the next 5 bits of HashKey.Value to pick a child, and bitmap records
+// which of the 32 possible children are present. Nodes are never changed
+// once built: set copies the path to the changed entry.
+type hamtNode struct {
+	bitmap  uint32
+	entries []hamtEntry
+}
+
+// hamtEntry is either a child node or a leaf holding every pair whose key has
+// the same HashKey.
+type hamtEntry struct {
+	node  *hamtNode
+	key   HashKey
+	pairs []HashPair
+}
+
+func (n *hamtNode) get(hashKey HashKey, shift uint, key Object) (Object, bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	entry, ok := n.find(hashKey, shift)
+	if !ok {
+		return nil, false
+	}
+	if entry.node != nil {
+		return entry.node.get(hashKey, shift+hamtBits, key)
+	}
+	if entry.key != hashKey {
+		return nil, false
+	}
+
+	for _, pair := range entry.pairs {
+		if Equals(pair.Key, key) {
+			return pair.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// set returns a copy of n with pair added, and whether the key wasn't there
+// before.
+func (n *hamtNode) set(hashKey HashKey, shift uint, pair HashPair) (*hamtNode, bool) {
+	if n == nil {
+		n = &hamtNode{}
+	}
+
+	if shift >= 64 {
+		return n.setCollision(hashKey, pair)
+	}
+
+	bit, pos := n.position(hashKey, shift)
+	if n.bitmap&bit == 0 {
+		entries := make([]hamtEntry, len(n.entries)+1)
+		copy(entries, n.entries[:pos])
+		entries[pos] = hamtEntry{key: hashKey, pairs: []HashPair{pair}}
+		copy(entries[pos+1:], n.entries[pos:])
+		return &hamtNode{bitmap: n.bitmap | bit, entries: entries}, true
+	}
+
+	entry := n.entries[pos]
+	var added bool
+	switch {
+	case entry.node != nil:
+		entry.node, added = entry.node.set(hashKey, shift+hamtBits, pair)
+	case entry.key == hashKey:
+		entry.pairs, added = setPair(entry.pairs, pair)
+	default:
+		node := newHamtNode(entry, shift+hamtBits)
+		entry = hamtEntry{}
+		entry.node, added = node.set(hashKey, shift+hamtBits, pair)
+	}
+
+	return n.withEntry(pos, entry), added
+}
+
+// delete returns a copy of n without the pair for key, and whether there was
+// one. Nodes left empty are removed and nodes left with a single leaf are
+// replaced by it, so the trie stays as shallow as after set.
+func (n *hamtNode) delete(hashKey HashKey, shift uint, key Object) (*hamtNode, bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	bit, pos, ok := n.locate(hashKey, shift)
+	if !ok {
+		return n, false
+	}
+
+	entry := n.entries[pos]
+	if entry.node != nil {
+		node, removed := entry.node.delete(hashKey, shift+hamtBits, key)
+		switch {
+		case !removed:
+			return n, false
+		case node == nil:
+			return n.withoutEntry(pos, bit), true
+		case len(node.entries) == 1 && node.entries[0].node == nil:
+			return n.withEntry(pos, node.entries[0]), true
+		default:
+			return n.withEntry(pos, hamtEntry{node: node}), true
+		}
+	}
+	if entry.key != hashKey {
+		return n, false
+	}
+
+	pairs, removed := deletePair(entry.pairs, key)
+	if !removed {
+		return n, false
+	}
+	if len(pairs) == 0 {
+		return n.withoutEntry(pos, bit), true
+	}
+
+	return n.withEntry(pos, hamtEntry{key: hashKey, pairs: pairs}), true
+}
+
+// newHamtNode returns a node holding only leaf, one level below a node where
+// it collided with another key.
+func newHamtNode(leaf hamtEntry, shift uint) *hamtNode {
+	var n *hamtNode
+	for _, pair := range leaf.pairs {
+		n, _ = n.set(leaf.key, shift, pair)
+	}
+
+	return n
+}
+
+// setCollision handles keys whose HashKey.Value is the same in every bit but
+// whose Type differs. Such nodes keep their entries in a plain list.
+func (n *hamtNode) setCollision(hashKey HashKey, pair HashPair) (*hamtNode, bool) {
+	for i, entry := range n.entries {
+		if entry.key == hashKey {
+			pairs, added := setPair(entry.pairs, pair)
+			return n.withEntry(i, hamtEntry{key: hashKey, pairs: pairs}), added
+		}
+	}
+
+	entries := make([]hamtEntry, len(n.entries), len(n.entries)+1)
+	copy(entries, n.entries)
+	entries = append(entries, hamtEntry{key: hashKey, pairs: []HashPair{pair}})
+	return &hamtNode{entries: entries}, true
+}
+
+func (n *hamtNode) find(hashKey HashKey, shift uint) (hamtEntry, bool) {
+	_, pos, ok := n.locate(hashKey, shift)
+	if !ok {
+		return hamtEntry{}, false
+	}
+
+	return n.entries[pos], true
+}
+
+// locate returns the bitmap bit and position of the entry for hashKey. The
+// bit is 0 in collision nodes, which have no bitmap.
+func (n *hamtNode) locate(hashKey HashKey, shift uint) (uint32, int, bool) {
+	if shift >= 64 {
+		for i, entry := range n.entries {
+			if entry.key == hashKey {
+				return 0, i, true
+			}
+		}
+		return 0, 0, false
+	}
+
+	bit, pos := n.position(hashKey, shift)
+	return bit, pos, n.bitmap&bit != 0
+}
+
+func (n *hamtNode) position(hashKey HashKey, shift uint) (uint32, int) {
+	bit := uint32(1) << ((hashKey.Value >> shift) & hamtMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode) withEntry(pos int, entry hamtEntry) *hamtNode {
+	entries := make([]hamtEntry, len(n.entries))
+	copy(entries, n.entries)
+	entries[pos] = entry
+	return &hamtNode{bitmap: n.bitmap, entries: entries}
+}
+
+// withoutEntry returns a copy of n without the entry at pos, or nil if that
+// was the only one.
+func (n *hamtNode) withoutEntry(pos int, bit uint32) *hamtNode {
+	if len(n.entries) == 1 {
+		return nil
+	}
+
+	entries := make([]hamtEntry, 0, len(n.entries)-1)
+	entries = append(entries, n.entries[:pos]...)
+	entries = append(entries, n.entries[pos+1:]...)
+	return &hamtNode{bitmap: n.bitmap &^ bit, entries: entries}
+}
+
+func (n *hamtNode) each(fn func(HashPair)) {
+	if n == nil {
+		return
+	}
+
+	for _, entry := range n.entries {
+		if entry.node != nil {
+			entry.node.each(fn)
+			continue
+		}
+		for _, pair := range entry.pairs {
+			fn(pair)
+		}
+	}
+}
+
+// setPair returns a copy of pairs with pair added or replacing the pair with
+// an equal key.
+func setPair(pairs []HashPair, pair HashPair) ([]HashPair, bool) {
+	copied := make([]HashPair, len(pairs), len(pairs)+1)
+	copy(copied, pairs)
+
+	for i, existing := range copied {
+		if Equals(existing.Key, pair.Key) {
+			copied[i] = pair
+			return copied, false
+		}
+	}
+
+	return append(copied, pair), true
+}
+
+// deletePair returns a copy of pairs without the pair whose key equals key.
+func deletePair(pairs []HashPair, key Object) ([]HashPair, bool) {
+	for i, existing := range pairs {
+		if Equals(existing.Key, key) {
+			copied := make([]HashPair, 0, len(pairs)-1)
+			copied = append(copied, pairs[:i]...)
+			return append(copied, pairs[i+1:]...), true
+		}
+	}
+
+	return pairs, false
+}
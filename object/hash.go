@@ -2,7 +2,11 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
 	"strings"
 )
 
@@ -16,8 +20,13 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash maps keys to values. It is backed by a hash array mapped trie, so
+// With returns a new Hash that shares all but one path of the trie with the
+// original. A lookup compares the keys that share a HashKey with Equals, so
+// keys whose HashKey collides don't replace each other.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	root *hamtNode
+	size int
 }
 
 func (h *Hash) Type() ObjectType {
@@ -27,8 +36,8 @@ func (h *Hash) Type() ObjectType {
 func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
-	pairs := make([]string, 0, len(h.Pairs))
-	for _, pair := range h.Pairs {
+	pairs := make([]string, 0, h.Len())
+	for _, pair := range h.SortedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
@@ -38,3 +47,225 @@ func (h *Hash) Inspect() string {
 
 	return out.String()
 }
+
+// HashKey combines the keys of every pair without depending on their order,
+// so equal hashes have the same key. Use HashKeyOf to check that all values
+// are hashable first.
+func (h *Hash) HashKey() HashKey {
+	var value uint64
+	for _, pair := range h.Pairs() {
+		hasher := fnv.New64a()
+		writeHashKey(hasher, pair.Key)
+		writeHashKey(hasher, pair.Value)
+		value += hasher.Sum64()
+	}
+
+	return HashKey{Type: h.Type(), Value: value}
+}
+
+// Len returns the number of pairs in the hash.
+func (h *Hash) Len() int {
+	return h.size
+}
+
+// Get returns the value stored for key.
+func (h *Hash) Get(key Object) (Object, bool) {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return nil, false
+	}
+
+	return h.root.get(hashKey, 0, key)
+}
+
+// Set stores value for key, replacing the value of an equal key. It returns
+// false if key can't be hashed. Set is meant for building a new Hash, use
+// With to add a key to a Hash that may already be shared.
+func (h *Hash) Set(key, value Object) bool {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return false
+	}
+
+	root, added := h.root.set(hashKey, 0, HashPair{Key: key, Value: value})
+	h.root = root
+	if added {
+		h.size++
+	}
+
+	return true
+}
+
+// With returns a new Hash with value stored for key, leaving h unchanged. It
+// returns false if key can't be hashed.
+func (h *Hash) With(key, value Object) (*Hash, bool) {
+	copied := *h
+	if !copied.Set(key, value) {
+		return nil, false
+	}
+
+	return &copied, true
+}
+
+// Without returns a new Hash without key, leaving h unchanged. It returns
+// false if key can't be hashed.
+func (h *Hash) Without(key Object) (*Hash, bool) {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return nil, false
+	}
+
+	copied := *h
+	root, removed := h.root.delete(hashKey, 0, key)
+	copied.root = root
+	if removed {
+		copied.size--
+	}
+
+	return &copied, true
+}
+
+// SortedPairs returns every pair in the hash, ordered by key. Keys are
+// ordered by type first: booleans, integers, strings, arrays and hashes.
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := h.Pairs()
+	sort.Slice(pairs, func(i, j int) bool {
+		return compareKeys(pairs[i].Key, pairs[j].Key) < 0
+	})
+
+	return pairs
+}
+
+// Pairs returns every pair in the hash, in no particular order.
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, h.size)
+	h.root.each(func(pair HashPair) {
+		pairs = append(pairs, pair)
+	})
+
+	return pairs
+}
+
+// HashKeyOf returns the HashKey of obj, or false if obj can't be used as a
+// hash key. Arrays and hashes can only be used when everything they contain
+// can be.
+func HashKeyOf(obj Object) (HashKey, bool) {
+	if !isHashable(obj) {
+		return HashKey{}, false
+	}
+
+	return obj.(Hashable).HashKey(), true
+}
+
+func isHashable(obj Object) bool {
+	switch obj := obj.(type) {
+	case *Array:
+		for _, el := range obj.Elements() {
+			if !isHashable(el) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		for _, pair := range obj.Pairs() {
+			if !isHashable(pair.Value) {
+				return false
+			}
+		}
+		return true
+	case Hashable:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeHashKey writes the HashKey of obj to w. Objects that can't be hashed
+// only write their type, which is still consistent with Equals.
+func writeHashKey(w io.Writer, obj Object) {
+	hashable, ok := obj.(Hashable)
+	if !ok {
+		w.Write([]byte(obj.Type()))
+		return
+	}
+
+	key := hashable.HashKey()
+	w.Write([]byte(key.Type))
+	binary.Write(w, binary.LittleEndian, key.Value)
+}
+
+func hashArg(name string, arg Object) (*Hash, *Error) {
+	hash, ok := arg.(*Hash)
+	if !ok {
+		return nil, newError(TypeError, "argument to `%s` must be a HASH, got %s", name, arg.Type())
+	}
+
+	return hash, nil
+}
+
+// compareKeys orders hash keys for SortedPairs.
+func compareKeys(a, b Object) int {
+	if rank := keyRank(a) - keyRank(b); rank != 0 {
+		return rank
+	}
+
+	switch a := a.(type) {
+	case *Boolean:
+		return compareBools(a.Value, b.(*Boolean).Value)
+	case *String:
+		return strings.Compare(a.Value, b.(*String).Value)
+	case *Array:
+		b := b.(*Array)
+		for i := 0; i < a.Len() && i < b.Len(); i++ {
+			if c := compareKeys(a.At(i), b.At(i)); c != 0 {
+				return c
+			}
+		}
+		return a.Len() - b.Len()
+	case *Hash:
+		aPairs, bPairs := a.SortedPairs(), b.(*Hash).SortedPairs()
+		for i := 0; i < len(aPairs) && i < len(bPairs); i++ {
+			if c := compareKeys(aPairs[i].Key, bPairs[i].Key); c != 0 {
+				return c
+			}
+			if c := compareKeys(aPairs[i].Value, bPairs[i].Value); c != 0 {
+				return c
+			}
+		}
+		return len(aPairs) - len(bPairs)
+	}
+
+	if IsInteger(a) {
+		return CompareIntegers(a, b)
+	}
+
+	return strings.Compare(a.Inspect(), b.Inspect())
+}
+
+func keyRank(obj Object) int {
+	switch obj.Type() {
+	case BOOLEAN_OBJ:
+		return 0
+	case INTEGER_OBJ, BIG_INT_OBJ:
+		return 1
+	case STRING_OBJ:
+		return 2
+	case ARRAY_OBJ:
+		return 3
+	case HASH_OBJ:
+		return 4
+	default:
+		return 5
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,199 @@
+package object_test
+
+import (
+	"monkey/object"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collidingKey always has the same HashKey, but is only equal to itself.
+type collidingKey struct{ name string }
+
+func (c *collidingKey) Type() object.ObjectType { return "COLLIDING" }
+func (c *collidingKey) Inspect() string         { return c.name }
+func (c *collidingKey) HashKey() object.HashKey { return object.HashKey{Type: "COLLIDING", Value: 42} }
+
+func TestHashCollisions(t *testing.T) {
+	a := &collidingKey{"a"}
+	b := &collidingKey{"b"}
+
+	hash := &object.Hash{}
+	require.True(t, hash.Set(a, &object.Integer{Value: 1}))
+	require.True(t, hash.Set(b, &object.Integer{Value: 2}))
+	assert.Equal(t, 2, hash.Len())
+
+	value, ok := hash.Get(a)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), value.(*object.Integer).Value)
+
+	value, ok = hash.Get(b)
+	require.True(t, ok)
+	assert.Equal(t, int64(2), value.(*object.Integer).Value)
+
+	hash.Set(a, &object.Integer{Value: 3})
+	assert.Equal(t, 2, hash.Len())
+	value, _ = hash.Get(a)
+	assert.Equal(t, int64(3), value.(*object.Integer).Value)
+}
+
+func TestCompositeHashKeys(t *testing.T) {
+	array := func(elements ...object.Object) *object.Array { return object.NewArray(elements) }
+	one := &object.Integer{Value: 1}
+	two := &object.Integer{Value: 2}
+
+	assert.Equal(t, array(one, two).HashKey(), array(&object.Integer{Value: 1}, &object.Integer{Value: 2}).HashKey(), "arrays with the same content have different hash keys")
+	assert.NotEqual(t, array(one, two).HashKey(), array(two, one).HashKey(), "arrays with the different content have the same hash keys")
+
+	ab := &object.Hash{}
+	ab.Set(&object.String{Value: "a"}, one)
+	ab.Set(&object.String{Value: "b"}, two)
+	ba := &object.Hash{}
+	ba.Set(&object.String{Value: "b"}, two)
+	ba.Set(&object.String{Value: "a"}, one)
+	assert.Equal(t, ab.HashKey(), ba.HashKey(), "hashes with the same content have different hash keys")
+
+	_, ok := object.HashKeyOf(array(one, array(two)))
+	assert.True(t, ok)
+	_, ok = object.HashKeyOf(array(one, &object.Builtin{}))
+	assert.False(t, ok, "arrays containing functions should not be hashable")
+}
+
+func TestHashWith(t *testing.T) {
+	const size = 5000
+
+	hash := &object.Hash{}
+	for i := 0; i < size; i++ {
+		var ok bool
+		hash, ok = hash.With(&object.Integer{Value: int64(i)}, &object.Integer{Value: int64(i * 2)})
+		require.True(t, ok)
+	}
+
+	require.Equal(t, size, hash.Len())
+	assert.Len(t, hash.Pairs(), size)
+	for i := 0; i < size; i++ {
+		value, ok := hash.Get(&object.Integer{Value: int64(i)})
+		require.True(t, ok, "missing key %d", i)
+		assert.Equal(t, int64(i*2), value.(*object.Integer).Value)
+	}
+
+	updated, _ := hash.With(&object.Integer{Value: 1}, &object.String{Value: "one"})
+	assert.Equal(t, size, updated.Len())
+	value, _ := updated.Get(&object.Integer{Value: 1})
+	assert.Equal(t, "one", value.Inspect())
+	value, _ = hash.Get(&object.Integer{Value: 1})
+	assert.Equal(t, "2", value.Inspect(), "With changed the original hash")
+
+	_, ok := hash.Get(&object.Integer{Value: size})
+	assert.False(t, ok)
+}
+
+func TestHashWithout(t *testing.T) {
+	const size = 5000
+
+	hash := &object.Hash{}
+	for i := 0; i < size; i++ {
+		hash, _ = hash.With(&object.Integer{Value: int64(i)}, &object.Integer{Value: int64(i)})
+	}
+
+	smaller := hash
+	for i := 0; i < size; i += 2 {
+		var ok bool
+		smaller, ok = smaller.Without(&object.Integer{Value: int64(i)})
+		require.True(t, ok)
+	}
+
+	assert.Equal(t, size, hash.Len(), "Without changed the original hash")
+	require.Equal(t, size/2, smaller.Len())
+	assert.Len(t, smaller.Pairs(), size/2)
+	for i := 0; i < size; i++ {
+		_, ok := smaller.Get(&object.Integer{Value: int64(i)})
+		assert.Equal(t, i%2 == 1, ok, "key %d", i)
+	}
+
+	same, ok := smaller.Without(&object.Integer{Value: 0})
+	require.True(t, ok)
+	assert.Equal(t, smaller.Len(), same.Len())
+
+	_, ok = smaller.Without(&object.Array{})
+	assert.True(t, ok)
+	_, ok = smaller.Without(&object.Builtin{})
+	assert.False(t, ok)
+}
+
+func TestHashWithoutCollisions(t *testing.T) {
+	a := &collidingKey{"a"}
+	b := &collidingKey{"b"}
+
+	hash := &object.Hash{}
+	hash.Set(a, &object.Integer{Value: 1})
+	hash.Set(b, &object.Integer{Value: 2})
+
+	withoutA, _ := hash.Without(a)
+	assert.Equal(t, 1, withoutA.Len())
+	_, ok := withoutA.Get(a)
+	assert.False(t, ok)
+	value, ok := withoutA.Get(b)
+	require.True(t, ok)
+	assert.Equal(t, "2", value.Inspect())
+
+	empty, _ := withoutA.Without(b)
+	assert.Equal(t, 0, empty.Len())
+	assert.Empty(t, empty.Pairs())
+}
+
+func TestSortedPairs(t *testing.T) {
+	hash := &object.Hash{}
+	keys := []object.Object{
+		&object.String{Value: "b"},
+		object.NewArray([]object.Object{&object.Integer{Value: 2}}),
+		&object.Integer{Value: 10},
+		object.TRUE,
+		&object.String{Value: "a"},
+		object.NewArray([]object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 5}}),
+		&object.Integer{Value: -3},
+		object.FALSE,
+	}
+	for _, key := range keys {
+		hash.Set(key, object.NULL)
+	}
+
+	var inspected []string
+	for _, pair := range hash.SortedPairs() {
+		inspected = append(inspected, pair.Key.Inspect())
+	}
+
+	assert.Equal(t, []string{"false", "true", "-3", "10", "a", "b", "[1, 5]", "[2]"}, inspected)
+}
+
+func TestHashKeysWithSameValue(t *testing.T) {
+	// An Integer and a String whose HashKeys share every bit of Value only
+	// differ by Type, so they end up in the same leaf of the trie.
+	str := &object.String{Value: "a"}
+	integer := &object.Integer{Value: int64(str.HashKey().Value)}
+
+	hash := &object.Hash{}
+	hash.Set(str, &object.Integer{Value: 1})
+	hash.Set(integer, &object.Integer{Value: 2})
+	assert.Equal(t, 2, hash.Len())
+
+	value, _ := hash.Get(str)
+	assert.Equal(t, "1", value.Inspect())
+	value, _ = hash.Get(integer)
+	assert.Equal(t, "2", value.Inspect())
+}
+
+func BenchmarkHashWith(b *testing.B) {
+	keys := make([]object.Object, 10000)
+	for i := range keys {
+		keys[i] = &object.Integer{Value: int64(i)}
+	}
+
+	for i := 0; i < b.N; i++ {
+		hash := &object.Hash{}
+		for _, key := range keys {
+			hash, _ = hash.With(key, key)
+		}
+	}
+}
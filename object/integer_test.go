@@ -1,6 +1,7 @@
 package object_test
 
 import (
+	"math/big"
 	"monkey/object"
 	"testing"
 
@@ -17,3 +18,13 @@ func TestIntegerHashKey(t *testing.T) {
 	assert.EqualValuesf(t, diff1.HashKey(), diff2.HashKey(), "integers with the same content have different hash keys")
 	assert.NotEqualValuesf(t, hello1.HashKey(), diff1.HashKey(), "integers with the different content have the same hash keys")
 }
+
+func TestBigIntHashKey(t *testing.T) {
+	huge1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	huge2, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	small := &object.BigInt{Value: big.NewInt(1)}
+
+	assert.Equal(t, (&object.BigInt{Value: huge1}).HashKey(), (&object.BigInt{Value: huge2}).HashKey(), "big integers with the same content have different hash keys")
+	assert.NotEqual(t, (&object.BigInt{Value: huge1}).HashKey(), (&object.BigInt{Value: new(big.Int).Neg(huge1)}).HashKey(), "big integers with different signs have the same hash keys")
+	assert.Equal(t, (&object.Integer{Value: 1}).HashKey(), small.HashKey(), "big integers that fit in an int64 should hash like integers")
+}
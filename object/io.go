@@ -0,0 +1,87 @@
+package object
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// IO is where the I/O builtins read and write. Hosts embedding an engine can
+// replace any of the streams, for example to capture the output of a script.
+type IO struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+}
+
+// StandardIO returns the streams of the process.
+func StandardIO() IO {
+	return IO{Stdout: os.Stdout, Stderr: os.Stderr, Stdin: os.Stdin}
+}
+
+// withDefaults returns s with the streams it leaves nil replaced by the ones
+// of the process.
+func (s IO) withDefaults() IO {
+	std := StandardIO()
+	if s.Stdout == nil {
+		s.Stdout = std.Stdout
+	}
+	if s.Stderr == nil {
+		s.Stderr = std.Stderr
+	}
+	if s.Stdin == nil {
+		s.Stdin = std.Stdin
+	}
+
+	return s
+}
+
+// IO returns the streams of the running program.
+func (r *Runtime) IO() IO {
+	return r.io
+}
+
+// SetIO changes the streams of the running program. Streams left nil are
+// the ones of the process. Like the arithmetic mode, they are kept between
+// runs.
+func (r *Runtime) SetIO(streams IO) {
+	r.io = streams.withDefaults()
+	r.stdin = nil
+}
+
+// ReadLine reads a line from stdin without its line ending. It returns false
+// once stdin is exhausted.
+func (r *Runtime) ReadLine() (string, bool, error) {
+	if r.stdin == nil {
+		if buffered, ok := r.io.Stdin.(*bufio.Reader); ok {
+			r.stdin = buffered
+		} else {
+			r.stdin = bufio.NewReader(r.io.Stdin)
+		}
+	}
+
+	line, err := r.stdin.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return "", false, nil
+		}
+		err = nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), true, nil
+}
+
+// writeLines writes the value of each object on a line of its own.
+func writeLines(w io.Writer, args []Object) error {
+	for _, arg := range args {
+		if _, err := io.WriteString(w, arg.Inspect()+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
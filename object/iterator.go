@@ -0,0 +1,99 @@
+package object
+
+import "unicode/utf8"
+
+// An Iterator produces the values of an Iterable one at a time. It is an
+// Object so engines can keep it on their stack while a loop runs.
+type Iterator interface {
+	Object
+	// Next returns the next value, or false once there are no more.
+	Next() (Object, bool)
+}
+
+// Iterable is implemented by the objects `for` loops can iterate over.
+// Arrays produce their elements, strings their characters, hashes their keys
+// in the order of SortedPairs and ranges their integers.
+type Iterable interface {
+	Iterate() Iterator
+}
+
+// Iterate returns an iterator over obj, or false if obj isn't iterable.
+func Iterate(obj Object) (Iterator, bool) {
+	iterable, ok := obj.(Iterable)
+	if !ok {
+		return nil, false
+	}
+
+	return iterable.Iterate(), true
+}
+
+// iteratorObject implements Object for the iterators.
+type iteratorObject struct{}
+
+func (iteratorObject) Type() ObjectType {
+	return ITERATOR_OBJ
+}
+
+func (iteratorObject) Inspect() string {
+	return "iterator"
+}
+
+func (a *Array) Iterate() Iterator {
+	return &arrayIterator{array: a}
+}
+
+type arrayIterator struct {
+	iteratorObject
+	array *Array
+	index int
+}
+
+func (it *arrayIterator) Next() (Object, bool) {
+	if it.index >= it.array.Len() {
+		return nil, false
+	}
+
+	value := it.array.At(it.index)
+	it.index++
+	return value, true
+}
+
+func (s *String) Iterate() Iterator {
+	return &stringIterator{value: s.Value}
+}
+
+type stringIterator struct {
+	iteratorObject
+	value  string
+	offset int
+}
+
+func (it *stringIterator) Next() (Object, bool) {
+	if it.offset >= len(it.value) {
+		return nil, false
+	}
+
+	char, size := utf8.DecodeRuneInString(it.value[it.offset:])
+	it.offset += size
+	return &String{Value: string(char)}, true
+}
+
+func (h *Hash) Iterate() Iterator {
+	return &hashIterator{pairs: h.SortedPairs()}
+}
+
+type hashIterator struct {
+	iteratorObject
+	pairs []HashPair
+	index int
+}
+
+func (it *hashIterator) Next() (Object, bool) {
+	if it.index >= len(it.pairs) {
+		return nil, false
+	}
+
+	key := it.pairs[it.index].Key
+	it.index++
+	return key, true
+}
@@ -0,0 +1,135 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// parseJSON converts a JSON document to Monkey values. JSON objects become
+// hashes, arrays become arrays and null becomes NULL. Monkey has no floats,
+// so numbers must be integers.
+func parseJSON(input string) Object {
+	decoder := json.NewDecoder(strings.NewReader(input))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return newError(ArgumentError, "invalid JSON: %s", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return newError(ArgumentError, "invalid JSON: unexpected data after top-level value")
+	}
+
+	return fromJSON(value)
+}
+
+func fromJSON(value any) Object {
+	switch value := value.(type) {
+	case nil:
+		return NULL
+	case bool:
+		return nativeBoolToBooleanObject(value)
+	case string:
+		return &String{Value: value}
+	case json.Number:
+		integer, ok := new(big.Int).SetString(value.String(), 10)
+		if !ok {
+			return newError(ArgumentError, "invalid JSON: number %s is not an integer", value)
+		}
+		return NewInteger(integer)
+	case []any:
+		elements := make([]Object, len(value))
+		for i, element := range value {
+			elements[i] = fromJSON(element)
+			if _, ok := elements[i].(*Error); ok {
+				return elements[i]
+			}
+		}
+		return NewArray(elements)
+	case map[string]any:
+		hash := &Hash{}
+		for key, element := range value {
+			converted := fromJSON(element)
+			if _, ok := converted.(*Error); ok {
+				return converted
+			}
+			hash.Set(&String{Value: key}, converted)
+		}
+		return hash
+	default:
+		return newError(ArgumentError, "invalid JSON: unexpected %T", value)
+	}
+}
+
+// stringifyJSON encodes obj as JSON. Hash keys are written in sorted order,
+// so the same value always gives the same document.
+func stringifyJSON(obj Object, indent int) Object {
+	var out bytes.Buffer
+	if err := writeJSON(&out, obj); err != nil {
+		return err
+	}
+	if indent == 0 {
+		return &String{Value: out.String()}
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out.Bytes(), "", strings.Repeat(" ", indent)); err != nil {
+		return newError(RuntimeError, "could not indent JSON: %s", err)
+	}
+
+	return &String{Value: indented.String()}
+}
+
+func writeJSON(out *bytes.Buffer, obj Object) *Error {
+	switch obj := obj.(type) {
+	case *Null:
+		out.WriteString("null")
+	case *Boolean, *Integer, *BigInt:
+		out.WriteString(obj.Inspect())
+	case *String:
+		writeJSONString(out, obj.Value)
+	case *Array:
+		out.WriteByte('[')
+		for i := 0; i < obj.Len(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := writeJSON(out, obj.At(i)); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case *Hash:
+		out.WriteByte('{')
+		for i, pair := range obj.SortedPairs() {
+			key, ok := pair.Key.(*String)
+			if !ok {
+				return newError(TypeError, "JSON object keys must be STRINGs, got %s", typeName(pair.Key))
+			}
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			writeJSONString(out, key.Value)
+			out.WriteByte(':')
+			if err := writeJSON(out, pair.Value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return newError(TypeError, "cannot convert %s to JSON", typeName(obj))
+	}
+
+	return nil
+}
+
+func writeJSONString(out *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	out.Truncate(out.Len() - 1) // Encode ends with a newline
+}
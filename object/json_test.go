@@ -0,0 +1,61 @@
+package object_test
+
+import (
+	"monkey/object"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": [1, 2, {"c": null}], "a": "x"}`, `{"a":"x","b":[1,2,{"c":null}]}`},
+		{`[true, false, -3, "a<b>\"c\""]`, `[true,false,-3,"a<b>\"c\""]`},
+		{`92233720368547758070`, `92233720368547758070`},
+		{`{"a": 1, "a": 2}`, `{"a":2}`},
+		{`"héllo\n"`, `"héllo\n"`},
+	}
+
+	parse := object.GetBuiltinByName("json_parse")
+	stringify := object.GetBuiltinByName("json_stringify")
+	for _, tt := range tests {
+		parsed := parse.Fn(nil, &object.String{Value: tt.input})
+		require.NotEqual(t, object.ERROR_OBJ, parsed.Type(), parsed.Inspect())
+
+		result := stringify.Fn(nil, parsed)
+		assert.Equal(t, tt.expected, result.Inspect())
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a": }`, "invalid JSON: invalid character '}' looking for beginning of value"},
+		{`[1.5]`, "invalid JSON: number 1.5 is not an integer"},
+		{`1 2`, "invalid JSON: unexpected data after top-level value"},
+		{``, "invalid JSON: EOF"},
+	}
+
+	parse := object.GetBuiltinByName("json_parse")
+	for _, tt := range tests {
+		result := parse.Fn(nil, &object.String{Value: tt.input})
+		err, ok := result.(*object.Error)
+		require.Truef(t, ok, "expected an error for %q, got %s", tt.input, result.Inspect())
+		assert.Equal(t, object.ArgumentError, err.Kind)
+		assert.Equal(t, tt.expected, err.Message)
+	}
+}
+
+func TestJSONIndent(t *testing.T) {
+	hash := &object.Hash{}
+	hash.Set(&object.String{Value: "a"}, object.NewArray([]object.Object{&object.Integer{Value: 1}}))
+
+	result := object.GetBuiltinByName("json_stringify").Fn(nil, hash, &object.Integer{Value: 2})
+	assert.Equal(t, "{\n  \"a\": [\n    1\n  ]\n}", result.Inspect())
+}
@@ -4,6 +4,7 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ           ObjectType = "INTEGER"
+	BIG_INT_OBJ           ObjectType = "BIG_INT"
 	BOOLEAN_OBJ           ObjectType = "BOOLEAN"
 	NULL_OBJ              ObjectType = "NULL"
 	RETURN_VALUE_OBJ      ObjectType = "RETURN_VALUE"
@@ -17,6 +18,16 @@ const (
 	QUOTE_OBJ             ObjectType = "QUOTE"
 	MACRO_OBJ             ObjectType = "MACRO"
 	CLOSURE_OBJ           ObjectType = "CLOSURE"
+	RANGE_OBJ             ObjectType = "RANGE"
+	ITERATOR_OBJ          ObjectType = "ITERATOR"
+)
+
+// The values shared by every engine, so builtins return the same booleans
+// and null the engines compare against.
+var (
+	NULL  = &Null{}
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
 )
 
 type Object interface {
@@ -0,0 +1,64 @@
+package object
+
+import "fmt"
+
+// Range is the integers from Start up to, but not including, End, counting
+// in steps of Step. Its values are only produced while iterating, so a range
+// takes the same memory whatever its length.
+type Range struct {
+	Start int64
+	End   int64
+	Step  int64
+}
+
+func (r *Range) Type() ObjectType {
+	return RANGE_OBJ
+}
+
+func (r *Range) Inspect() string {
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.End, r.Step)
+}
+
+// Len returns the number of values in the range. The `range` builtin only
+// creates ranges whose length fits in an int64.
+func (r *Range) Len() int64 {
+	return int64(r.count())
+}
+
+// count returns the number of values in the range, which is more than
+// math.MaxInt64 for ranges like the one over every int64.
+func (r *Range) count() uint64 {
+	switch {
+	case r.Step > 0 && r.Start < r.End:
+		return (uint64(r.End-r.Start)-1)/uint64(r.Step) + 1
+	case r.Step < 0 && r.Start > r.End:
+		return (uint64(r.Start-r.End)-1)/-uint64(r.Step) + 1
+	default:
+		return 0
+	}
+}
+
+func (r *Range) Iterate() Iterator {
+	return &rangeIterator{next: r.Start, remaining: r.count(), step: r.Step}
+}
+
+type rangeIterator struct {
+	iteratorObject
+	next      int64
+	remaining uint64
+	step      int64
+}
+
+func (it *rangeIterator) Next() (Object, bool) {
+	if it.remaining == 0 {
+		return nil, false
+	}
+
+	value := it.next
+	it.remaining--
+	if it.remaining > 0 {
+		it.next += it.step
+	}
+
+	return &Integer{Value: value}, true
+}
@@ -0,0 +1,96 @@
+package object_test
+
+import (
+	"math"
+	"monkey/object"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeLen(t *testing.T) {
+	tests := []struct {
+		r        object.Range
+		expected int64
+	}{
+		{object.Range{Start: 0, End: 10, Step: 1}, 10},
+		{object.Range{Start: 0, End: 100, Step: 7}, 15},
+		{object.Range{Start: 10, End: 0, Step: -3}, 4},
+		{object.Range{Start: 5, End: 5, Step: 1}, 0},
+		{object.Range{Start: 5, End: 0, Step: 1}, 0},
+		{object.Range{Start: 0, End: 5, Step: -1}, 0},
+		{object.Range{Start: math.MinInt64, End: math.MaxInt64, Step: math.MaxInt64}, 3},
+		{object.Range{Start: math.MaxInt64, End: math.MinInt64, Step: math.MinInt64}, 2},
+		{object.Range{Start: 0, End: math.MaxInt64, Step: 1}, math.MaxInt64},
+		{object.Range{Start: math.MaxInt64, End: 0, Step: -1}, math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.r.Len(), tt.r.Inspect())
+	}
+}
+
+func TestRangeIterate(t *testing.T) {
+	tests := []struct {
+		r        object.Range
+		expected []int64
+	}{
+		{object.Range{Start: 0, End: 4, Step: 1}, []int64{0, 1, 2, 3}},
+		{object.Range{Start: 9, End: 0, Step: -2}, []int64{9, 7, 5, 3, 1}},
+		{object.Range{Start: 0, End: 0, Step: 1}, nil},
+		{object.Range{Start: math.MaxInt64 - 1, End: math.MaxInt64, Step: 5}, []int64{math.MaxInt64 - 1}},
+		{object.Range{Start: math.MinInt64, End: math.MaxInt64, Step: math.MaxInt64}, []int64{math.MinInt64, -1, math.MaxInt64 - 1}},
+	}
+
+	for _, tt := range tests {
+		var actual []int64
+		it := tt.r.Iterate()
+		for value, ok := it.Next(); ok; value, ok = it.Next() {
+			actual = append(actual, value.(*object.Integer).Value)
+		}
+		assert.Equal(t, tt.expected, actual, tt.r.Inspect())
+	}
+}
+
+func TestIterate(t *testing.T) {
+	hash := &object.Hash{}
+	hash.Set(&object.String{Value: "b"}, &object.Integer{Value: 2})
+	hash.Set(&object.String{Value: "a"}, &object.Integer{Value: 1})
+
+	tests := []struct {
+		obj      object.Object
+		expected []string
+	}{
+		{object.NewArray([]object.Object{&object.Integer{Value: 1}, &object.String{Value: "x"}}), []string{"1", "x"}},
+		{&object.String{Value: "héllo"}, []string{"h", "é", "l", "l", "o"}},
+		{hash, []string{"a", "b"}},
+		{&object.Range{Start: 1, End: 3, Step: 1}, []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		it, ok := object.Iterate(tt.obj)
+		require.True(t, ok, tt.obj.Inspect())
+		assert.Equal(t, object.ITERATOR_OBJ, it.Type())
+
+		var actual []string
+		for value, ok := it.Next(); ok; value, ok = it.Next() {
+			actual = append(actual, value.Inspect())
+		}
+		assert.Equal(t, tt.expected, actual)
+	}
+
+	_, ok := object.Iterate(&object.Integer{Value: 1})
+	assert.False(t, ok)
+}
+
+func TestRangeIterateLongerThanInt64(t *testing.T) {
+	r := &object.Range{Start: math.MinInt64, End: math.MaxInt64, Step: 1}
+	it := r.Iterate()
+
+	for _, expected := range []int64{math.MinInt64, math.MinInt64 + 1, math.MinInt64 + 2} {
+		value, ok := it.Next()
+		require.True(t, ok)
+		assert.Equal(t, expected, value.(*object.Integer).Value)
+	}
+}
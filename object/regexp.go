@@ -0,0 +1,99 @@
+package object
+
+import "regexp"
+
+// maxCachedPatterns limits how many compiled patterns a runtime keeps, so a
+// script building patterns in a loop can't grow the cache without bound.
+const maxCachedPatterns = 128
+
+// patternCache keeps compiled regular expressions by their source. Once it is
+// full, the pattern that was added first is dropped.
+type patternCache struct {
+	patterns map[string]*regexp.Regexp
+	order    []string
+}
+
+func (c *patternCache) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := c.patterns[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.patterns == nil {
+		c.patterns = make(map[string]*regexp.Regexp)
+	}
+	if len(c.order) == maxCachedPatterns {
+		delete(c.patterns, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.patterns[pattern] = re
+	c.order = append(c.order, pattern)
+
+	return re, nil
+}
+
+// Regexp compiles pattern with Go's regexp syntax. Compiled patterns are
+// cached, so builtins called in a loop only compile their pattern once.
+func (r *Runtime) Regexp(pattern string) (*regexp.Regexp, error) {
+	return r.patterns.compile(pattern)
+}
+
+// regexpArgs checks that args are n strings, the second of which is a valid
+// pattern, and returns their values and the compiled pattern.
+func regexpArgs(engine Engine, name string, args []Object, n int) ([]string, *regexp.Regexp, *Error) {
+	strs, err := stringArgs(name, args, n)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	re, compileErr := engine.Runtime().Regexp(strs[1])
+	if compileErr != nil {
+		return nil, nil, newError(ArgumentError, "invalid pattern passed to `%s`: %s", name, compileErr)
+	}
+
+	return strs, re, nil
+}
+
+// captures converts a match, as returned by FindStringSubmatchIndex, to a
+// hash of its capture groups. Every group is keyed by its index, with 0 the
+// whole match, and named groups by their name too. Groups that didn't take
+// part in the match are NULL.
+func captures(re *regexp.Regexp, s string, match []int) *Hash {
+	hash := &Hash{}
+	names := re.SubexpNames()
+
+	for i := 0; i < len(match)/2; i++ {
+		var value Object = NULL
+		if match[2*i] >= 0 {
+			value = &String{Value: s[match[2*i]:match[2*i+1]]}
+		}
+
+		hash.Set(&Integer{Value: int64(i)}, value)
+		if names[i] != "" {
+			hash.Set(&String{Value: names[i]}, value)
+		}
+	}
+
+	return hash
+}
+
+// findAll returns every match of re in s. Without capture groups the matches
+// are strings, otherwise they are hashes of their groups like `re_match`
+// returns.
+func findAll(re *regexp.Regexp, s string) *Array {
+	if re.NumSubexp() == 0 {
+		return stringArray(re.FindAllString(s, -1))
+	}
+
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	elements := make([]Object, len(matches))
+	for i, match := range matches {
+		elements[i] = captures(re, s, match)
+	}
+
+	return NewArray(elements)
+}
@@ -0,0 +1,35 @@
+package object_test
+
+import (
+	"fmt"
+	"monkey/object"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegexpCache(t *testing.T) {
+	runtime := object.NewRuntime()
+
+	first, err := runtime.Regexp(`\d+`)
+	require.NoError(t, err)
+	second, err := runtime.Regexp(`\d+`)
+	require.NoError(t, err)
+	assert.Same(t, first, second, "pattern was compiled twice")
+
+	other, err := object.NewRuntime().Regexp(`\d+`)
+	require.NoError(t, err)
+	assert.NotSame(t, first, other, "runtimes share their cache")
+
+	for i := 0; i < 128; i++ {
+		_, err := runtime.Regexp(fmt.Sprintf("p%d", i))
+		require.NoError(t, err)
+	}
+	evicted, err := runtime.Regexp(`\d+`)
+	require.NoError(t, err)
+	assert.NotSame(t, first, evicted, "oldest pattern was not evicted")
+
+	_, err = runtime.Regexp("(")
+	assert.Error(t, err)
+}
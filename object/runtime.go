@@ -0,0 +1,147 @@
+package object
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+var (
+	ErrCancelled         = errors.New("execution cancelled")
+	ErrBudgetExceeded    = errors.New("step budget exceeded")
+	ErrCallDepthExceeded = errors.New("maximum call depth exceeded")
+)
+
+// DefaultMaxCallDepth limits how deeply calls may nest in engines that don't
+// keep a call stack of their own. Like the VM's MaxFrames, it keeps runaway
+// recursion from exhausting the Go stack.
+const DefaultMaxCallDepth = 1024
+
+// The context is only checked every cancelCheckInterval steps, as checking it
+// is a lot more expensive than counting a step.
+const cancelCheckInterval = 1024
+
+// Runtime holds the state of a single run of an engine: the context it runs
+// under and how many steps it may still take. It also holds the settings
+// kept between runs, like the streams the I/O builtins use.
+type Runtime struct {
+	ctx      context.Context
+	maxSteps int
+	steps    int
+	err      error
+	calls    Stack
+
+	maxCallDepth int
+	arithmetic   ArithmeticMode
+	io           IO
+	stdin        *bufio.Reader
+	files        FilePolicy
+	clock        Clock
+	random       *rand.Rand
+	patterns     patternCache
+}
+
+func NewRuntime() *Runtime {
+	r := &Runtime{ctx: context.Background(), io: StandardIO(), maxCallDepth: DefaultMaxCallDepth}
+	r.SetClock(nil)
+	r.SetRandom(nil)
+	return r
+}
+
+// Begin starts a run that is cancelled once ctx is done and that may take at
+// most maxSteps steps. A maxSteps of 0 means there is no limit.
+func (r *Runtime) Begin(ctx context.Context, maxSteps int) {
+	r.ctx = ctx
+	r.maxSteps = maxSteps
+	r.steps = 0
+	r.err = nil
+	r.calls = r.calls[:0]
+
+	if err := ctx.Err(); err != nil {
+		r.err = fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+}
+
+// End finishes a run, later steps are no longer limited.
+func (r *Runtime) End() {
+	r.Begin(context.Background(), 0)
+}
+
+// Step records that the engine took a step. It returns an error wrapping
+// ErrCancelled or ErrBudgetExceeded once the run has to stop, and keeps
+// returning it for every step after that.
+func (r *Runtime) Step() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.steps++
+	if r.maxSteps > 0 && r.steps > r.maxSteps {
+		r.err = fmt.Errorf("%w: limit of %d steps", ErrBudgetExceeded, r.maxSteps)
+	} else if r.steps%cancelCheckInterval == 0 {
+		if err := r.ctx.Err(); err != nil {
+			r.err = fmt.Errorf("%w: %w", ErrCancelled, err)
+		}
+	}
+
+	return r.err
+}
+
+// Err returns the error that stopped the current run, if any.
+func (r *Runtime) Err() error {
+	return r.err
+}
+
+// PushCall records that frame's function was called, for engines that don't
+// keep a call stack of their own. It returns ErrCallDepthExceeded instead if
+// the call would nest deeper than the maximum call depth.
+func (r *Runtime) PushCall(frame StackFrame) error {
+	if len(r.calls) >= r.maxCallDepth {
+		return ErrCallDepthExceeded
+	}
+
+	r.calls = append(r.calls, frame)
+	return nil
+}
+
+// PopCall records that the most recent call returned.
+func (r *Runtime) PopCall() {
+	r.calls = r.calls[:len(r.calls)-1]
+}
+
+// CallStack returns a copy of the active calls, the most recent call first.
+func (r *Runtime) CallStack() Stack {
+	stack := make(Stack, len(r.calls))
+	for i, frame := range r.calls {
+		stack[len(r.calls)-1-i] = frame
+	}
+
+	return stack
+}
+
+// MaxCallDepth returns how deeply calls may nest.
+func (r *Runtime) MaxCallDepth() int {
+	return r.maxCallDepth
+}
+
+// SetMaxCallDepth changes how deeply calls may nest. A depth of 0 or less is
+// DefaultMaxCallDepth. Like the arithmetic mode, it is kept between runs.
+func (r *Runtime) SetMaxCallDepth(depth int) {
+	if depth <= 0 {
+		depth = DefaultMaxCallDepth
+	}
+	r.maxCallDepth = depth
+}
+
+// Arithmetic returns how integer arithmetic handles overflow.
+func (r *Runtime) Arithmetic() ArithmeticMode {
+	return r.arithmetic
+}
+
+// SetArithmetic changes how integer arithmetic handles overflow. Unlike the
+// limits passed to Begin, the mode is kept between runs.
+func (r *Runtime) SetArithmetic(mode ArithmeticMode) {
+	r.arithmetic = mode
+}
@@ -0,0 +1,51 @@
+package object_test
+
+import (
+	"bytes"
+	"context"
+	"monkey/object"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeStep(t *testing.T) {
+	runtime := object.NewRuntime()
+	runtime.Begin(context.Background(), 2)
+
+	assert.NoError(t, runtime.Step())
+	assert.NoError(t, runtime.Step())
+	assert.ErrorIs(t, runtime.Step(), object.ErrBudgetExceeded)
+	assert.ErrorIs(t, runtime.Step(), object.ErrBudgetExceeded, "error should stick")
+
+	runtime.End()
+	assert.NoError(t, runtime.Step())
+	assert.NoError(t, runtime.Err())
+}
+
+func TestRuntimeReadLine(t *testing.T) {
+	runtime := object.NewRuntime()
+	runtime.SetIO(object.IO{Stdin: strings.NewReader("first\r\nsecond\nlast")})
+
+	for _, expected := range []string{"first", "second", "last"} {
+		line, ok, err := runtime.ReadLine()
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, expected, line)
+	}
+
+	_, ok, err := runtime.ReadLine()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRuntimeIODefaults(t *testing.T) {
+	var out bytes.Buffer
+	runtime := object.NewRuntime()
+	runtime.SetIO(object.IO{Stdout: &out})
+
+	assert.Equal(t, &out, runtime.IO().Stdout)
+	assert.Equal(t, object.StandardIO().Stderr, runtime.IO().Stderr)
+	assert.Equal(t, object.StandardIO().Stdin, runtime.IO().Stdin)
+}
@@ -0,0 +1,39 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"monkey/token"
+)
+
+// StackFrame is a Monkey function call that was active when an error
+// occurred: the name of the function and where it was called from.
+type StackFrame struct {
+	Function string
+	Pos      token.Position
+}
+
+func (f StackFrame) String() string {
+	name := f.Function
+	if name == "" {
+		name = "<anonymous>"
+	}
+
+	return fmt.Sprintf("%s (called at %s)", name, f.Pos)
+}
+
+// Stack is a call stack, the most recent call comes first.
+type Stack []StackFrame
+
+// String formats the stack as a traceback.
+func (s Stack) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("Traceback (most recent call first):")
+	for _, frame := range s {
+		out.WriteString("\n\tin ")
+		out.WriteString(frame.String())
+	}
+
+	return out.String()
+}
@@ -0,0 +1,21 @@
+package object_test
+
+import (
+	"monkey/object"
+	"monkey/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackString(t *testing.T) {
+	stack := object.Stack{
+		{Function: "first", Pos: token.Position{Line: 2, Column: 3}},
+		{Function: "", Pos: token.Position{Line: 5, Column: 1}},
+	}
+
+	expected := "Traceback (most recent call first):\n" +
+		"\tin first (called at 2:3)\n" +
+		"\tin <anonymous> (called at 5:1)"
+	assert.Equal(t, expected, stack.String())
+}
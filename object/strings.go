@@ -0,0 +1,49 @@
+package object
+
+import "unicode/utf8"
+
+// maxStringLength limits the strings builtins like `repeat` build, so a
+// script can't exhaust memory with a single call.
+const maxStringLength = 1 << 30
+
+// stringArgs checks that args are n strings and returns their values.
+func stringArgs(name string, args []Object, n int) ([]string, *Error) {
+	if len(args) != n {
+		return nil, newError(ArgumentError, "wrong number of arguments. got=%d, want=%d", len(args), n)
+	}
+
+	strs := make([]string, n)
+	for i, arg := range args {
+		str, ok := arg.(*String)
+		if !ok {
+			return nil, newError(TypeError, "argument to `%s` must be a STRING, got %s", name, arg.Type())
+		}
+		strs[i] = str.Value
+	}
+
+	return strs, nil
+}
+
+func intArg(name string, arg Object) (int64, *Error) {
+	integer, ok := arg.(*Integer)
+	if !ok {
+		return 0, newError(TypeError, "argument to `%s` must be an INTEGER, got %s", name, arg.Type())
+	}
+
+	return integer.Value, nil
+}
+
+func stringArray(strs []string) *Array {
+	elements := make([]Object, len(strs))
+	for i, str := range strs {
+		elements[i] = &String{Value: str}
+	}
+
+	return NewArray(elements)
+}
+
+// isCharBoundary reports whether the byte offset i of s is the start or end
+// of a character rather than inside one.
+func isCharBoundary(s string, i int64) bool {
+	return i == int64(len(s)) || utf8.RuneStart(s[i])
+}
@@ -0,0 +1,135 @@
+package object
+
+const (
+	vectorBits  = 5
+	vectorWidth = 1 << vectorBits
+	vectorMask  = vectorWidth - 1
+)
+
+// vector is a persistent vector: a trie of 32-element nodes with the last
+// elements kept in a separate tail. Pushing copies at most one path through
+// the trie, so vectors built from each other share most of their nodes.
+// Elements before offset have been dropped by rest and are never read again.
+type vector struct {
+	count  int
+	shift  uint
+	root   *vectorNode
+	tail   []Object
+	offset int
+}
+
+type vectorNode struct {
+	children []*vectorNode
+	values   []Object
+}
+
+func newVector(elements []Object) vector {
+	v := vector{shift: vectorBits, root: &vectorNode{}}
+	for _, el := range elements {
+		v = v.push(el)
+	}
+
+	return v
+}
+
+func (v vector) len() int {
+	return v.count - v.offset
+}
+
+func (v vector) at(i int) Object {
+	i += v.offset
+	if i >= v.tailOffset() {
+		return v.tail[i-v.tailOffset()]
+	}
+
+	node := v.root
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.children[(i>>level)&vectorMask]
+	}
+
+	return node.values[i&vectorMask]
+}
+
+func (v vector) push(obj Object) vector {
+	if v.root == nil {
+		v = newVector(nil)
+	}
+
+	if v.count-v.tailOffset() < vectorWidth {
+		tail := make([]Object, len(v.tail)+1)
+		copy(tail, v.tail)
+		tail[len(v.tail)] = obj
+
+		v.tail = tail
+		v.count++
+		return v
+	}
+
+	tailNode := &vectorNode{values: v.tail}
+	if v.count>>vectorBits > 1<<v.shift {
+		v.root = &vectorNode{children: []*vectorNode{v.root, newVectorPath(v.shift, tailNode)}}
+		v.shift += vectorBits
+	} else {
+		v.root = v.pushTail(v.shift, v.root, tailNode)
+	}
+
+	v.tail = []Object{obj}
+	v.count++
+	return v
+}
+
+// rest drops the first element without copying anything.
+func (v vector) rest() vector {
+	v.offset++
+	return v
+}
+
+func (v vector) slice() []Object {
+	elements := make([]Object, v.len())
+	for i := range elements {
+		elements[i] = v.at(i)
+	}
+
+	return elements
+}
+
+func (v vector) tailOffset() int {
+	if v.count < vectorWidth {
+		return 0
+	}
+
+	return ((v.count - 1) >> vectorBits) << vectorBits
+}
+
+func (v vector) pushTail(level uint, parent, tailNode *vectorNode) *vectorNode {
+	index := ((v.count - 1) >> level) & vectorMask
+
+	node := &vectorNode{children: make([]*vectorNode, len(parent.children))}
+	copy(node.children, parent.children)
+
+	var child *vectorNode
+	switch {
+	case level == vectorBits:
+		child = tailNode
+	case index < len(parent.children):
+		child = v.pushTail(level-vectorBits, parent.children[index], tailNode)
+	default:
+		child = newVectorPath(level-vectorBits, tailNode)
+	}
+
+	if index < len(node.children) {
+		node.children[index] = child
+	} else {
+		node.children = append(node.children, child)
+	}
+
+	return node
+}
+
+func newVectorPath(level uint, node *vectorNode) *vectorNode {
+	if level == 0 {
+		return node
+	}
+
+	return &vectorNode{children: []*vectorNode{newVectorPath(level-vectorBits, node)}}
+}
@@ -57,6 +57,16 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	testutil.AssertLiteralExpression(t, stmt.Expression, 5)
 }
 
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	input := "123456789012345678901234567890;"
+	program := testutil.SetupProgram(t, input, 1)
+	stmt := testutil.AssertExpressionStatement(t, program.Statements[0])
+	literal, ok := stmt.Expression.(*ast.BigIntegerLiteral)
+	require.Truef(t, ok, "expected expression to be BigIntegerLiteral, got %T", stmt.Expression)
+	assert.Equal(t, "123456789012345678901234567890", literal.Value.String())
+	assert.Equal(t, "123456789012345678901234567890", literal.String())
+}
+
 func TestStringLiteralExpression(t *testing.T) {
 	input := `"hello world";`
 	program := testutil.SetupProgram(t, input, 1)
@@ -185,6 +195,22 @@ func TestIfElseExpression(t *testing.T) {
 	testutil.AssertIdentifier(t, alternative.Expression, "y")
 }
 
+func TestForExpression(t *testing.T) {
+	input := "for (x in range(10)) { x }"
+	program := testutil.SetupProgram(t, input, 1)
+	stmt := testutil.AssertExpressionStatement(t, program.Statements[0])
+	exp, ok := stmt.Expression.(*ast.ForExpression)
+	require.Truef(t, ok, "expected expression to be ForExpression, got %T", stmt.Expression)
+	testutil.AssertIdentifier(t, exp.Variable, "x")
+	call, ok := exp.Iterable.(*ast.CallExpression)
+	require.Truef(t, ok, "expected iterable to be CallExpression, got %T", exp.Iterable)
+	testutil.AssertIdentifier(t, call.Function, "range")
+	assert.Len(t, exp.Body.Statements, 1)
+	body := testutil.AssertExpressionStatement(t, exp.Body.Statements[0])
+	testutil.AssertIdentifier(t, body.Expression, "x")
+	assert.Equal(t, "for (x in range(10)) x", exp.String())
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := "fn(x, y) { x + y }"
 	program := testutil.SetupProgram(t, input, 1)
@@ -2,6 +2,7 @@ package repl
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 
@@ -16,8 +17,10 @@ import (
 const PROMPT = ">> "
 
 func StartVm(scanner *bufio.Scanner, out io.Writer) {
+	streams := replIO(scanner, out)
 	constants := []object.Object{}
-	globals := make([]object.Object, vm.GlobalsSize)
+	globals := []object.Object{}
+	macroEnv := object.NewEnvironment()
 	symbolTable := compiler.NewSymbolTable()
 	for i, v := range object.Builtins {
 		symbolTable.DefineBuiltin(i, v.Name)
@@ -35,8 +38,14 @@ func StartVm(scanner *bufio.Scanner, out io.Writer) {
 			continue
 		}
 
+		expanded, err := evaluator.ProcessMacros(macroEnv, program)
+		if err != nil {
+			fmt.Fprintf(out, "Uh oh! Macro expansion failed:\n%s\n", err)
+			continue
+		}
+
 		comp := compiler.NewWithState(symbolTable, constants)
-		err := comp.Compile(program)
+		err = comp.Compile(expanded)
 		if err != nil {
 			fmt.Fprintf(out, "Uh oh! Compilation failed:\n%s\n", err)
 			continue
@@ -45,19 +54,29 @@ func StartVm(scanner *bufio.Scanner, out io.Writer) {
 		constants = code.Constants
 
 		machine := vm.NewWithGlobalsStore(code, globals)
+		machine.Runtime().SetIO(streams)
 		err = machine.Run()
+		globals = machine.Globals()
 		if err != nil {
 			fmt.Fprintf(out, "Uh oh! Executing bytecode failed:\n%s\n", err)
+
+			var runtimeErr *vm.RuntimeError
+			if errors.As(err, &runtimeErr) {
+				printStack(out, runtimeErr.Stack)
+			}
+			continue
 		}
 
 		lastPopped := machine.LastPoppedStackElem()
-		io.WriteString(out, lastPopped.Inspect())
-		io.WriteString(out, "\n")
+		if lastPopped != nil {
+			printObject(out, lastPopped)
+		}
 	}
 }
 
 func StartEval(scanner *bufio.Scanner, out io.Writer) {
 	env := object.NewEnvironment()
+	env.Runtime().SetIO(replIO(scanner, out))
 	macroEnv := object.NewEnvironment()
 
 	for {
@@ -72,14 +91,68 @@ func StartEval(scanner *bufio.Scanner, out io.Writer) {
 			continue
 		}
 
-		evaluator.DefineMacros(macroEnv, program)
-		expanded := evaluator.ExpandMacros(macroEnv, program)
+		expanded, err := evaluator.ProcessMacros(macroEnv, program)
+		if err != nil {
+			fmt.Fprintf(out, "Uh oh! Macro expansion failed:\n%s\n", err)
+			continue
+		}
+
 		evaluated := evaluator.Eval(env, expanded)
 		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+			printObject(out, evaluated)
+		}
+	}
+}
+
+// replIO returns the streams for programs run by the REPL. They write to out
+// and read the lines the user types after the program, so `read_line` doesn't
+// compete with the REPL for input.
+func replIO(scanner *bufio.Scanner, out io.Writer) object.IO {
+	return object.IO{
+		Stdout: out,
+		Stderr: out,
+		Stdin:  bufio.NewReader(&scannerReader{scanner: scanner}),
+	}
+}
+
+// scannerReader reads the lines of a scanner, one line per Read.
+type scannerReader struct {
+	scanner *bufio.Scanner
+	pending []byte
+}
+
+func (r *scannerReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
 		}
+		r.pending = append([]byte(r.scanner.Text()), '\n')
 	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func printObject(out io.Writer, obj object.Object) {
+	io.WriteString(out, obj.Inspect())
+	io.WriteString(out, "\n")
+
+	if err, ok := obj.(*object.Error); ok {
+		printStack(out, err.Stack)
+	}
+}
+
+func printStack(out io.Writer, stack object.Stack) {
+	if len(stack) == 0 {
+		return
+	}
+
+	io.WriteString(out, stack.String())
+	io.WriteString(out, "\n")
 }
 
 func printParserErrors(out io.Writer, errors []string) {
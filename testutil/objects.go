@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"math/big"
 	"monkey/evaluator"
 	"monkey/object"
 	"testing"
@@ -17,10 +18,14 @@ func AssertObject(t *testing.T, actual object.Object, expected any) {
 		AssertIntegerObject(t, actual, int64(expected))
 	case []int:
 		AssertIntegerArray(t, actual, expected)
+	case []string:
+		AssertStringArray(t, actual, expected)
 	case map[object.HashKey]int64:
 		AssertIntegerHash(t, actual, expected)
 	case int64:
 		AssertIntegerObject(t, actual, expected)
+	case *big.Int:
+		AssertBigIntObject(t, actual, expected)
 	case bool:
 		AssertBooleanObject(t, actual, expected)
 	case string:
@@ -40,6 +45,14 @@ func AssertIntegerObject(t *testing.T, actual object.Object, expected int64) {
 	assert.Equal(t, expected, result.Value)
 }
 
+func AssertBigIntObject(t *testing.T, actual object.Object, expected *big.Int) {
+	t.Helper()
+
+	result, ok := actual.(*object.BigInt)
+	require.Truef(t, ok, "object is not a BigInt, got %T (%+v)", actual, actual)
+	assert.Equalf(t, 0, expected.Cmp(result.Value), "expected %s, got %s", expected, result.Value)
+}
+
 func AssertBooleanObject(t *testing.T, actual object.Object, expected bool) {
 	t.Helper()
 
@@ -67,9 +80,20 @@ func AssertIntegerArray(t *testing.T, actual object.Object, expected []int) {
 
 	array, ok := actual.(*object.Array)
 	require.Truef(t, ok, "object us not an Array, got %T, (%+v)", actual, actual)
-	assert.Len(t, array.Elements, len(expected))
+	require.Equal(t, len(expected), array.Len())
+	for i, expectedElem := range expected {
+		AssertIntegerObject(t, array.At(i), int64(expectedElem))
+	}
+}
+
+func AssertStringArray(t *testing.T, actual object.Object, expected []string) {
+	t.Helper()
+
+	array, ok := actual.(*object.Array)
+	require.Truef(t, ok, "object is not an Array, got %T, (%+v)", actual, actual)
+	require.Equal(t, len(expected), array.Len())
 	for i, expectedElem := range expected {
-		AssertIntegerObject(t, array.Elements[i], int64(expectedElem))
+		AssertStringObject(t, array.At(i), expectedElem)
 	}
 }
 
@@ -77,10 +101,11 @@ func AssertIntegerHash(t *testing.T, actual object.Object, expected map[object.H
 	t.Helper()
 	hash, ok := actual.(*object.Hash)
 	require.Truef(t, ok, "object is not a Hash, got %T (%+v)", actual, actual)
-	assert.Len(t, hash.Pairs, len(expected), "hash has wrong number of Pairs")
-	for expectedKey, expectedValue := range expected {
-		pair, ok := hash.Pairs[expectedKey]
-		require.Truef(t, ok, "no pair for gived key in Pairs %v", expectedKey)
+	assert.Equal(t, len(expected), hash.Len(), "hash has wrong number of Pairs")
+	for _, pair := range hash.Pairs() {
+		key, _ := object.HashKeyOf(pair.Key)
+		expectedValue, ok := expected[key]
+		require.Truef(t, ok, "unexpected key in Pairs %s", pair.Key.Inspect())
 		AssertIntegerObject(t, pair.Value, expectedValue)
 	}
 }
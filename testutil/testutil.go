@@ -29,8 +29,11 @@ func SetupProgram(t *testing.T, input string, stmtLen int) *ast.Program {
 func Compile(t *testing.T, input string) *compiler.Compiler {
 	t.Helper()
 	program := SetupProgram(t, input, 0)
+	program, err := evaluator.ProcessMacros(object.NewEnvironment(), program)
+	require.NoError(t, err)
+
 	comp := compiler.New()
-	err := comp.Compile(program)
+	err = comp.Compile(program)
 	require.NoError(t, err)
 	return comp
 }
@@ -0,0 +1,13 @@
+package token
+
+import "fmt"
+
+// Position is a location in the source, both Line and Column start at 1.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
@@ -47,11 +47,14 @@ const (
 	ELSE     TokenType = "ELSE"
 	RETURN   TokenType = "RETURN"
 	MACRO    TokenType = "MACRO"
+	FOR      TokenType = "FOR"
+	IN       TokenType = "IN"
 )
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
 }
 
 var keywords = map[string]TokenType{
@@ -63,6 +66,8 @@ var keywords = map[string]TokenType{
 	"else":   ELSE,
 	"return": RETURN,
 	"macro":  MACRO,
+	"for":    FOR,
+	"in":     IN,
 }
 
 func LookupIdent(ident string) TokenType {
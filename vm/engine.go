@@ -0,0 +1,41 @@
+package vm
+
+import "monkey/object"
+
+// Call calls fn with args on top of the current stack and runs it to
+// completion, so builtins can call back into Monkey functions. If the call
+// fails, the VM stops with that error once the builtin returns.
+func (vm *VM) Call(fn object.Object, args ...object.Object) object.Object {
+	if vm.callbackErr != nil {
+		return raisedError(vm.callbackErr)
+	}
+
+	stopAt := vm.framesIndex
+	err := vm.push(fn)
+	for _, arg := range args {
+		if err != nil {
+			break
+		}
+		err = vm.push(arg)
+	}
+	if err == nil {
+		err = vm.executeCall(len(args))
+	}
+	if err == nil && vm.framesIndex > stopAt {
+		err = vm.execute(stopAt)
+	}
+
+	if err != nil {
+		vm.callbackErr = err
+		return raisedError(err)
+	}
+
+	return vm.pop()
+}
+
+// Runtime returns the runtime shared by everything the VM runs.
+func (vm *VM) Runtime() *object.Runtime {
+	return vm.runtime
+}
+
+var _ object.Engine = (*VM)(nil)
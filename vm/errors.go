@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"monkey/code"
+	"monkey/object"
+)
+
+// RuntimeError is returned by Run when executing the bytecode fails. Object
+// is the Monkey error, with the same kind, cause and position the evaluator
+// reports, and Stack the Monkey calls that were active at that moment.
+type RuntimeError struct {
+	Err    error
+	Object *object.Error
+	Stack  object.Stack
+}
+
+func newRuntimeError(err error, stack object.Stack) *RuntimeError {
+	kind := object.RuntimeError
+	var kindErr *kindError
+	if errors.As(err, &kindErr) {
+		kind = kindErr.kind
+	}
+
+	return &RuntimeError{
+		Err:    err,
+		Object: &object.Error{Kind: kind, Message: err.Error(), Stack: stack},
+		Stack:  stack,
+	}
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Object != nil {
+		return e.Object.Error()
+	}
+
+	return e.Err.Error()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// raisedError returns err as a raised Monkey error, with the kind it would
+// have if it stopped the VM.
+func raisedError(err error) *object.Error {
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Object == nil {
+		runtimeErr = newRuntimeError(err, nil)
+	}
+
+	return runtimeErr.Object.Raise()
+}
+
+// kindError gives an error the VM raises itself the kind the evaluator
+// raises the same error with. Errors without one are RuntimeErrors.
+type kindError struct {
+	kind string
+	err  error
+}
+
+func withKind(kind string, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+// callStack returns the active calls, the most recent call first.
+func (vm *VM) callStack() object.Stack {
+	stack := object.Stack{}
+
+	for i := vm.framesIndex - 1; i > 0; i-- {
+		stack = append(stack, object.StackFrame{
+			Function: vm.frames[i].cl.Fn.Name,
+			Pos:      vm.frames[i].calledAt,
+		})
+	}
+
+	return stack
+}
+
+// operandError returns a RuntimeError for the first operand that is an error
+// object, with the stack of where that error occurred, so using the result
+// of a failed builtin reports the original error.
+func operandError(operands ...object.Object) error {
+	for _, operand := range operands {
+		if err, ok := operand.(*object.Error); ok {
+			return &RuntimeError{Err: err, Object: err, Stack: err.Stack}
+		}
+	}
+
+	return nil
+}
+
+func functionName(fn *object.CompiledFunction) string {
+	if fn.Name == "" {
+		return "<anonymous>"
+	}
+
+	return fn.Name
+}
+
+func opcodeName(op code.Opcode) string {
+	def, err := code.Lookup(byte(op))
+	if err != nil {
+		return fmt.Sprintf("opcode %d", op)
+	}
+
+	return def.Name
+}
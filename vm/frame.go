@@ -3,6 +3,7 @@ package vm
 import (
 	"monkey/code"
 	"monkey/object"
+	"monkey/token"
 )
 
 // A Frame holds execution-relevant information.
@@ -10,6 +11,7 @@ type Frame struct {
 	cl          *object.Closure // Closure for compiled function referenced by this Frame
 	ip          int             // Instruction pointer in this Frame, for this Frame
 	basepointer int             // The pointer value before the function was executed
+	calledAt    token.Position  // Source position of the call that started this Frame
 }
 
 func NewFrame(cl *object.Closure, basePointer int) *Frame {
@@ -19,3 +21,9 @@ func NewFrame(cl *object.Closure, basePointer int) *Frame {
 func (f *Frame) Instructions() code.Instructions {
 	return f.cl.Fn.Instructions
 }
+
+// callSite returns the source position of the call the frame is executing.
+// The instruction pointer is on the operand of the OpCall or OpTailCall then.
+func (f *Frame) callSite() token.Position {
+	return f.cl.Fn.Positions[f.ip-1]
+}
@@ -0,0 +1,88 @@
+package vm
+
+import (
+	"math/rand/v2"
+	"monkey/object"
+)
+
+// GrowthPolicy decides how the VM allocates its stack, frames and globals.
+type GrowthPolicy int
+
+const (
+	// GrowOnDemand starts with small allocations and doubles them as needed,
+	// up to the limits in Options.
+	GrowOnDemand GrowthPolicy = iota
+	// Preallocate allocates every limit in full when the VM is created.
+	Preallocate
+)
+
+// initialSize is the number of slots a growable stack, frame list or globals
+// store starts with.
+const initialSize = 64
+
+// Options configures the limits of a VM, how it does integer arithmetic, the
+// streams its I/O builtins use, which files scripts may access and the clock
+// and random number generator of the time and random builtins. Zero limits
+// fall back to the package defaults StackSize, MaxFrames and GlobalsSize,
+// nil streams to the ones of the process, a nil Clock to the system clock
+// and a nil Random to a randomly seeded generator. The zero Files policy
+// denies all file access.
+type Options struct {
+	StackSize   int
+	MaxFrames   int
+	GlobalsSize int
+	Growth      GrowthPolicy
+	Arithmetic  object.ArithmeticMode
+	IO          object.IO
+	Files       object.FilePolicy
+	Clock       object.Clock
+	Random      *rand.Rand
+}
+
+// DefaultOptions returns the limits used by New.
+func DefaultOptions() Options {
+	return Options{
+		StackSize:   StackSize,
+		MaxFrames:   MaxFrames,
+		GlobalsSize: GlobalsSize,
+		Growth:      GrowOnDemand,
+	}
+}
+
+func (o Options) withDefaults() Options {
+	if o.StackSize <= 0 {
+		o.StackSize = StackSize
+	}
+	if o.MaxFrames <= 0 {
+		o.MaxFrames = MaxFrames
+	}
+	if o.GlobalsSize <= 0 {
+		o.GlobalsSize = GlobalsSize
+	}
+
+	return o
+}
+
+func (o Options) initial(limit int) int {
+	if o.Growth == Preallocate || limit < initialSize {
+		return limit
+	}
+
+	return initialSize
+}
+
+// grow returns a copy of slots with room for at least size elements, doubling
+// its length but never going past limit.
+func grow[T any](slots []T, size, limit int) []T {
+	n := len(slots) * 2
+	if n < size {
+		n = size
+	}
+	if n > limit {
+		n = limit
+	}
+
+	grown := make([]T, n)
+	copy(grown, slots)
+	return grown
+}
@@ -1,10 +1,13 @@
 package vm
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"monkey/code"
 	"monkey/compiler"
 	"monkey/object"
+	"monkey/token"
 )
 
 const (
@@ -14,9 +17,9 @@ const (
 )
 
 var (
-	True  = &object.Boolean{Value: true}
-	False = &object.Boolean{Value: false}
-	Null  = &object.Null{}
+	True  = object.TRUE
+	False = object.FALSE
+	Null  = object.NULL
 )
 
 type VM struct {
@@ -30,32 +33,64 @@ type VM struct {
 
 	frames      []*Frame
 	framesIndex int
+
+	runtime *object.Runtime
+	options Options
+
+	// callbackErr is the error that stopped a function called by a builtin
+	// through Call. It is returned once the builtin is done.
+	callbackErr error
 }
 
 func New(bytecode *compiler.Bytecode) *VM {
-	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions}
+	return NewWithOptions(bytecode, DefaultOptions())
+}
+
+// NewWithOptions creates a VM whose stack, call depth and globals are limited
+// by opts.
+func NewWithOptions(bytecode *compiler.Bytecode, opts Options) *VM {
+	opts = opts.withDefaults()
+
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions, Positions: bytecode.Positions}
 	mainClosure := &object.Closure{Fn: mainFn}
 	mainFrame := NewFrame(mainClosure, 0)
 
-	frames := make([]*Frame, MaxFrames)
+	runtime := object.NewRuntime()
+	runtime.SetArithmetic(opts.Arithmetic)
+	runtime.SetIO(opts.IO)
+	runtime.SetFilePolicy(opts.Files)
+	runtime.SetClock(opts.Clock)
+	runtime.SetRandom(opts.Random)
+
+	frames := make([]*Frame, opts.initial(opts.MaxFrames))
 	frames[0] = mainFrame
 
 	return &VM{
 		constants:   bytecode.Constants,
-		stack:       make([]object.Object, StackSize),
+		stack:       make([]object.Object, opts.initial(opts.StackSize)),
 		sp:          0,
-		globals:     make([]object.Object, GlobalsSize),
+		globals:     make([]object.Object, opts.initial(opts.GlobalsSize)),
 		frames:      frames,
 		framesIndex: 1,
+		runtime:     runtime,
+		options:     opts,
 	}
 }
 
+// NewWithGlobalsStore creates a VM that starts from the globals in s. The
+// store may grow while running, so callers that keep globals between runs
+// should pick them up again with Globals.
 func NewWithGlobalsStore(bytecode *compiler.Bytecode, s []object.Object) *VM {
 	vm := New(bytecode)
 	vm.globals = s
 	return vm
 }
 
+// Globals returns the VM's globals store.
+func (vm *VM) Globals() []object.Object {
+	return vm.globals
+}
+
 func (vm *VM) StackTop() object.Object {
 	if vm.sp == 0 {
 		return nil
@@ -65,15 +100,58 @@ func (vm *VM) StackTop() object.Object {
 }
 
 func (vm *VM) LastPoppedStackElem() object.Object {
+	if vm.sp >= len(vm.stack) {
+		return nil
+	}
+
 	return vm.stack[vm.sp]
 }
 
 func (vm *VM) Run() error {
+	return vm.RunContext(context.Background(), 0)
+}
+
+// RunContext runs the bytecode like Run, but stops once ctx is done or after
+// maxSteps instructions, returning an error that wraps object.ErrCancelled or
+// object.ErrBudgetExceeded. A maxSteps of 0 means there is no limit.
+func (vm *VM) RunContext(ctx context.Context, maxSteps int) error {
+	vm.runtime.Begin(ctx, maxSteps)
+	defer vm.runtime.End()
+
+	if err := vm.run(); err != nil {
+		var runtimeErr *RuntimeError
+		if errors.As(err, &runtimeErr) {
+			return err
+		}
+
+		return newRuntimeError(err, vm.callStack())
+	}
+
+	return nil
+}
+
+func (vm *VM) run() error {
+	return vm.execute(0)
+}
+
+// execute runs instructions until the frame at index stopAt returns, or
+// until the main function ends when stopAt is 0.
+func (vm *VM) execute(stopAt int) (err error) {
 	var ip int
 	var ins code.Instructions
 	var op code.Opcode
 
-	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error executing %s at ip %d: %v", opcodeName(op), ip, r)
+		}
+	}()
+
+	for vm.framesIndex > stopAt && vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		if err := vm.runtime.Step(); err != nil {
+			return err
+		}
+
 		vm.currentFrame().ip++
 
 		ip = vm.currentFrame().ip
@@ -134,7 +212,28 @@ func (vm *VM) Run() error {
 			vm.currentFrame().ip += 2
 
 			condition := vm.pop()
-			if !isTruthy(condition) {
+			if !object.IsTruthy(condition) {
+				vm.currentFrame().ip = pos - 1
+			}
+
+		case code.OpIterator:
+			err := vm.executeIterator(vm.pop())
+			if err != nil {
+				return err
+			}
+
+		case code.OpIterNext:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			iterator := vm.stack[vm.sp-1].(object.Iterator)
+			if value, ok := iterator.Next(); ok {
+				err := vm.push(value)
+				if err != nil {
+					return err
+				}
+			} else {
+				vm.pop()
 				vm.currentFrame().ip = pos - 1
 			}
 
@@ -147,7 +246,12 @@ func (vm *VM) Run() error {
 		case code.OpGetGlobal:
 			globalIndex := code.ReadUint16(ins[ip+1:])
 			vm.currentFrame().ip += 2
-			err := vm.push(vm.globals[globalIndex])
+			var global object.Object
+			if int(globalIndex) < len(vm.globals) {
+				global = vm.globals[globalIndex]
+			}
+
+			err := vm.push(global)
 			if err != nil {
 				return err
 			}
@@ -155,6 +259,11 @@ func (vm *VM) Run() error {
 		case code.OpSetGlobal:
 			globalIndex := code.ReadUint16(ins[ip+1:])
 			vm.currentFrame().ip += 2
+
+			err := vm.ensureGlobals(int(globalIndex) + 1)
+			if err != nil {
+				return err
+			}
 			vm.globals[globalIndex] = vm.pop()
 
 		case code.OpGetLocal:
@@ -234,6 +343,15 @@ func (vm *VM) Run() error {
 				return err
 			}
 
+		case code.OpTailCall:
+			numArgs := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			err := vm.executeTailCall(int(numArgs))
+			if err != nil {
+				return err
+			}
+
 		case code.OpReturn:
 			frame := vm.popFrame()
 			vm.sp = frame.basepointer - 1
@@ -276,8 +394,11 @@ func (vm *VM) Run() error {
 }
 
 func (vm *VM) push(obj object.Object) error {
-	if vm.sp >= StackSize {
-		return fmt.Errorf("stack overflow")
+	if err := vm.ensureStack(vm.sp + 1); err != nil {
+		if vm.framesIndex > 1 {
+			return fmt.Errorf("%w in %s", err, functionName(vm.currentFrame().cl.Fn))
+		}
+		return err
 	}
 
 	vm.stack[vm.sp] = obj
@@ -299,44 +420,50 @@ func (vm *VM) executeBinaryOperation(op code.Opcode) error {
 	right := vm.pop()
 	left := vm.pop()
 
+	if err := operandError(left, right); err != nil {
+		return err
+	}
+
 	leftType := left.Type()
 	rightType := right.Type()
 
 	switch {
-	case leftType == object.INTEGER_OBJ && rightType == object.INTEGER_OBJ:
+	case object.IsInteger(left) && object.IsInteger(right):
 		return vm.executeBinaryIntegerOperation(op, left, right)
 	case leftType == object.STRING_OBJ && rightType == object.STRING_OBJ:
 		return vm.executeBinaryStringOperation(op, left, right)
 	default:
-		return fmt.Errorf("unsupported types for binary operation: %s %s", leftType, rightType)
+		return withKind(object.TypeError, fmt.Errorf("unsupported types for binary operation: %s %s", leftType, rightType))
 	}
 }
 
 func (vm *VM) executeBinaryIntegerOperation(op code.Opcode, left, right object.Object) error {
-	leftValue := left.(*object.Integer).Value
-	rightValue := right.(*object.Integer).Value
-
-	var result int64
+	var operator string
 
 	switch op {
 	case code.OpAdd:
-		result = leftValue + rightValue
+		operator = "+"
 	case code.OpDiv:
-		result = leftValue / rightValue
+		operator = "/"
 	case code.OpMul:
-		result = leftValue * rightValue
+		operator = "*"
 	case code.OpSub:
-		result = leftValue - rightValue
+		operator = "-"
 	default:
 		return fmt.Errorf("unknown integer operation: %d", op)
 	}
 
-	return vm.push(&object.Integer{Value: result})
+	result, err := vm.runtime.Arithmetic().Infix(operator, left, right)
+	if err != nil {
+		return withKind(object.ArithmeticError, fmt.Errorf("%w: %s %s %s", err, left.Inspect(), operator, right.Inspect()))
+	}
+
+	return vm.push(result)
 }
 
 func (vm *VM) executeBinaryStringOperation(op code.Opcode, left, right object.Object) error {
 	if op != code.OpAdd {
-		return fmt.Errorf("unknown string operator: %d", op)
+		return withKind(object.TypeError, fmt.Errorf("unknown string operator: %d", op))
 	}
 
 	leftValue := left.(*object.String).Value
@@ -349,30 +476,29 @@ func (vm *VM) executeComparison(op code.Opcode) error {
 	right := vm.pop()
 	left := vm.pop()
 
-	if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
+	if object.IsInteger(left) && object.IsInteger(right) {
 		return vm.executeIntegerComparison(op, left, right)
 	}
 
 	switch op {
 	case code.OpEqual:
-		return vm.push(nativeBoolToBooleanObject(right == left))
+		return vm.push(nativeBoolToBooleanObject(object.Equals(left, right)))
 	case code.OpNotEqual:
-		return vm.push(nativeBoolToBooleanObject(right != left))
+		return vm.push(nativeBoolToBooleanObject(!object.Equals(left, right)))
 	default:
-		return fmt.Errorf("unknown operator: %d (%s %s)", op, left.Type(), right.Type())
+		return withKind(object.TypeError, fmt.Errorf("unknown operator: %d (%s %s)", op, left.Type(), right.Type()))
 	}
 }
 
 func (vm *VM) executeIntegerComparison(op code.Opcode, left, right object.Object) error {
-	leftValue := left.(*object.Integer).Value
-	rightValue := right.(*object.Integer).Value
+	cmp := object.CompareIntegers(left, right)
 	switch op {
 	case code.OpEqual:
-		return vm.push(nativeBoolToBooleanObject(rightValue == leftValue))
+		return vm.push(nativeBoolToBooleanObject(cmp == 0))
 	case code.OpNotEqual:
-		return vm.push(nativeBoolToBooleanObject(rightValue != leftValue))
+		return vm.push(nativeBoolToBooleanObject(cmp != 0))
 	case code.OpGreaterThan:
-		return vm.push(nativeBoolToBooleanObject(leftValue > rightValue))
+		return vm.push(nativeBoolToBooleanObject(cmp > 0))
 	default:
 		return fmt.Errorf("unknown operator: %d", op)
 	}
@@ -389,39 +515,26 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 func (vm *VM) executeBangOperator() error {
 	operand := vm.pop()
 
-	switch operand {
-	case True:
-		return vm.push(False)
-	case False:
-		return vm.push(True)
-	case Null:
-		return vm.push(True)
-	default:
-		return vm.push(False)
-	}
+	return vm.push(nativeBoolToBooleanObject(!object.IsTruthy(operand)))
 }
 
 func (vm *VM) executeMinusOperator() error {
 	operand := vm.pop()
 
-	if operand.Type() != object.INTEGER_OBJ {
-		return fmt.Errorf("unsupported type for negation: %s", operand.Type())
+	if err := operandError(operand); err != nil {
+		return err
 	}
 
-	value := operand.(*object.Integer).Value
-	return vm.push(&object.Integer{Value: -value})
-
-}
+	if !object.IsInteger(operand) {
+		return withKind(object.TypeError, fmt.Errorf("unsupported type for negation: %s", operand.Type()))
+	}
 
-func isTruthy(obj object.Object) bool {
-	switch obj := obj.(type) {
-	case *object.Boolean:
-		return obj.Value
-	case *object.Null:
-		return false
-	default:
-		return true
+	result, err := vm.runtime.Arithmetic().Negate(operand)
+	if err != nil {
+		return withKind(object.ArithmeticError, fmt.Errorf("%w: -(%s)", err, operand.Inspect()))
 	}
+
+	return vm.push(result)
 }
 
 func (vm *VM) buildArray(startIndex, endIndex int) object.Object {
@@ -430,72 +543,132 @@ func (vm *VM) buildArray(startIndex, endIndex int) object.Object {
 		elements[i-startIndex] = vm.stack[i]
 	}
 
-	return &object.Array{Elements: elements}
+	return object.NewArray(elements)
 }
 
 func (vm *VM) buildHash(startIndex, endIndex int) (object.Object, error) {
-	hashedPairs := make(map[object.HashKey]object.HashPair)
+	hash := &object.Hash{}
 
 	for i := startIndex; i < endIndex; i += 2 {
 		key := vm.stack[i]
 		value := vm.stack[i+1]
 
-		pair := object.HashPair{Key: key, Value: value}
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
-			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+		if !hash.Set(key, value) {
+			return nil, withKind(object.TypeError, fmt.Errorf("unusable as hash key: %s", key.Type()))
 		}
-
-		hashedPairs[hashKey.HashKey()] = pair
 	}
 
-	return &object.Hash{Pairs: hashedPairs}, nil
+	return hash, nil
 }
 
 func (vm *VM) executeIndexExpression(left, index object.Object) error {
+	if err := operandError(left, index); err != nil {
+		return err
+	}
+
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return vm.executeArrayIndex(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return vm.executeHashIndex(left, index)
 	default:
-		return fmt.Errorf("index operator not supported: %s", index.Type())
+		return withKind(object.TypeError, fmt.Errorf("index operator not supported: %s", index.Type()))
 	}
 }
 
+func (vm *VM) executeIterator(iterable object.Object) error {
+	if err := operandError(iterable); err != nil {
+		return err
+	}
+
+	iterator, ok := object.Iterate(iterable)
+	if !ok {
+		return withKind(object.TypeError, fmt.Errorf("cannot iterate over %s", iterable.Type()))
+	}
+
+	return vm.push(iterator)
+}
+
 func (vm *VM) executeArrayIndex(array, index object.Object) error {
 	arrayObject := array.(*object.Array)
 	i := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
+	max := int64(arrayObject.Len() - 1)
 	if i < 0 || i > max {
 		return vm.push(Null)
 	}
 
-	return vm.push(arrayObject.Elements[i])
+	return vm.push(arrayObject.At(int(i)))
 }
 
 func (vm *VM) executeHashIndex(hash, index object.Object) error {
 	hashObject := hash.(*object.Hash)
-	key, ok := index.(object.Hashable)
-	if !ok {
-		return fmt.Errorf("unusable as hash key: %s", index.Type())
+	if _, ok := object.HashKeyOf(index); !ok {
+		return withKind(object.TypeError, fmt.Errorf("unusable as hash key: %s", index.Type()))
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	value, ok := hashObject.Get(index)
 	if !ok {
 		return vm.push(Null)
 	}
 
-	return vm.push(pair.Value)
+	return vm.push(value)
+}
+
+// ensureStack makes room for size slots on the stack, growing it if the
+// growth policy allows.
+func (vm *VM) ensureStack(size int) error {
+	if size <= len(vm.stack) {
+		return nil
+	}
+	if size > vm.options.StackSize {
+		return fmt.Errorf("stack overflow")
+	}
+
+	vm.stack = grow(vm.stack, size, vm.options.StackSize)
+	return nil
+}
+
+// ensureCallStack makes room for the locals of fn, whose frame starts at
+// basepointer, and for the first value fn pushes, so running out of stack is
+// reported as calling fn.
+func (vm *VM) ensureCallStack(basepointer int, fn *object.CompiledFunction) error {
+	if err := vm.ensureStack(basepointer + fn.NumLocals + 1); err != nil {
+		return fmt.Errorf("%w calling %s", err, functionName(fn))
+	}
+
+	return nil
+}
+
+// ensureGlobals makes room for size globals, growing the store if needed.
+func (vm *VM) ensureGlobals(size int) error {
+	if size <= len(vm.globals) {
+		return nil
+	}
+	if size > vm.options.GlobalsSize {
+		return fmt.Errorf("too many globals: limit is %d", vm.options.GlobalsSize)
+	}
+
+	vm.globals = grow(vm.globals, size, vm.options.GlobalsSize)
+	return nil
 }
 
 func (vm *VM) currentFrame() *Frame {
 	return vm.frames[vm.framesIndex-1]
 }
 
-func (vm *VM) pushFrame(f *Frame) {
+func (vm *VM) pushFrame(f *Frame) error {
+	if vm.framesIndex >= len(vm.frames) {
+		if vm.framesIndex >= vm.options.MaxFrames {
+			return fmt.Errorf("maximum call depth of %d exceeded calling %s", vm.options.MaxFrames, functionName(f.cl.Fn))
+		}
+
+		vm.frames = grow(vm.frames, vm.framesIndex+1, vm.options.MaxFrames)
+	}
+
 	vm.frames[vm.framesIndex] = f
 	vm.framesIndex++
+
+	return nil
 }
 
 func (vm *VM) pushClosure(constIndex, numFree int) error {
@@ -528,27 +701,75 @@ func (vm *VM) executeCall(numArgs int) error {
 	case *object.Builtin:
 		return vm.callBuiltin(callee, numArgs)
 	default:
-		return fmt.Errorf("calling non-closure and non-built-in")
+		return withKind(object.TypeError, fmt.Errorf("calling non-closure and non-built-in"))
 	}
 }
 
 func (vm *VM) callClosure(cl *object.Closure, numArgs int) error {
 	if numArgs != cl.Fn.NumParameters {
-		return fmt.Errorf("wrong number of arguments: want=%d, got=%d", cl.Fn.NumParameters, numArgs)
+		return withKind(object.ArgumentError, fmt.Errorf("wrong number of arguments: want=%d, got=%d", cl.Fn.NumParameters, numArgs))
 	}
 
 	frame := NewFrame(cl, vm.sp-numArgs)
-	vm.pushFrame(frame)
+	frame.calledAt = vm.currentFrame().callSite()
+	if err := vm.ensureCallStack(frame.basepointer, cl.Fn); err != nil {
+		return err
+	}
+
+	err := vm.pushFrame(frame)
+	if err != nil {
+		return err
+	}
 	vm.sp = frame.basepointer + cl.Fn.NumLocals
 
 	return nil
 }
 
+// executeTailCall calls a closure by reusing the current frame: the callee
+// and its arguments replace the current closure and its locals on the stack.
+// Builtins don't need a frame and are called as usual.
+func (vm *VM) executeTailCall(numArgs int) error {
+	callee, ok := vm.stack[vm.sp-1-numArgs].(*object.Closure)
+	if !ok {
+		return vm.executeCall(numArgs)
+	}
+
+	if numArgs != callee.Fn.NumParameters {
+		return withKind(object.ArgumentError, fmt.Errorf("wrong number of arguments: want=%d, got=%d", callee.Fn.NumParameters, numArgs))
+	}
+
+	frame := vm.currentFrame()
+	if err := vm.ensureCallStack(frame.basepointer, callee.Fn); err != nil {
+		return err
+	}
+
+	copy(vm.stack[frame.basepointer-1:], vm.stack[vm.sp-1-numArgs:vm.sp])
+	frame.calledAt = frame.callSite()
+	frame.cl = callee
+	frame.ip = -1
+	vm.sp = frame.basepointer + callee.Fn.NumLocals
+
+	return nil
+}
+
 func (vm *VM) callBuiltin(builtin *object.Builtin, numArgs int) error {
 	args := vm.stack[vm.sp-numArgs : vm.sp]
-	result := builtin.Fn(args...)
+	result := builtin.Fn(vm, args...)
 	vm.sp = vm.sp - numArgs - 1
 
+	if err := vm.callbackErr; err != nil {
+		vm.callbackErr = nil
+		return err
+	}
+
+	if err, ok := result.(*object.Error); ok && err.Stack == nil {
+		frame := object.StackFrame{Function: object.BuiltinName(builtin), Pos: vm.currentFrame().callSite()}
+		err.Stack = append(object.Stack{frame}, vm.callStack()...)
+		if err.Pos == (token.Position{}) {
+			err.Pos = frame.Pos
+		}
+	}
+
 	if result != nil {
 		vm.push(result)
 	} else {
@@ -1,10 +1,19 @@
 package vm_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"monkey/code"
+	"monkey/compiler"
 	"monkey/object"
 	"monkey/testutil"
+	"monkey/token"
 	"monkey/vm"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -62,6 +71,24 @@ func TestBooleanExpressions(t *testing.T) {
 		{"!!5", true},
 		{"!(if (false) {5})", true},
 		{"if ((if (false) {10})) {10} else {20}", 20},
+		{`[1, 2] == [1, 2]`, true},
+		{`[1, 2] != [1, 2]`, false},
+		{`[1, 2] == [2, 1]`, false},
+		{`[1, 2] == [1, 2, 3]`, false},
+		{`[[1, "a"], [true]] == [[1, "a"], [true]]`, true},
+		{`[[1, "a"], [true]] == [[1, "b"], [true]]`, false},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{"a": {"b": [1]}} == {"a": {"b": [1]}}`, true},
+		{`"monkey" == "mon" + "key"`, true},
+		{`"monkey" != "monkey"`, false},
+		{`[9223372036854775807 + 1] == [9223372036854775808]`, true},
+		{`[] == []`, true},
+		{`[1] == 1`, false},
+		{`[if (false) { 1 }] == [if (false) { 2 }]`, true},
+		{`let f = fn() { 1 }; f == f`, true},
+		{`fn() { 1 } == fn() { 1 }`, false},
 	}
 
 	runVmTest(t, tests)
@@ -131,6 +158,11 @@ func TestIndexExpressions(t *testing.T) {
 		{"{1: 1, 2: 2}[2]", 2},
 		{"{1: 1}[0]", vm.Null},
 		{"{}[0]", vm.Null},
+		{`{[1, 2]: "pair"}[[1, 2]]`, "pair"},
+		{`{[1, [2, 3]]: "nested"}[[1, [2, 3]]]`, "nested"},
+		{`{[1, 2]: "pair"}[[2, 1]]`, vm.Null},
+		{`{{"a": 1, "b": 2}: "hash"}[{"b": 2, "a": 1}]`, "hash"},
+		{`{[9223372036854775808]: "big"}[[9223372036854775807 + 1]]`, "big"},
 	}
 
 	runVmTest(t, tests)
@@ -293,6 +325,178 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`push([], 1)`, []int{1}},
 		{`push(1)`, &object.Error{Message: "wrong number of arguments. got=1, want=2"}},
 		{`push(1, 1)`, &object.Error{Message: "argument to `push` must be an ARRAY, got INTEGER"}},
+		{`set({}, 1, 2)`, map[object.HashKey]int64{(&object.Integer{Value: 1}).HashKey(): 2}},
+		{`set({1: 2}, 1, 3)[1]`, 3},
+		{`let a = {1: 2}; let b = set(a, 3, 4); a[3]`, vm.Null},
+		{`set(1, 1, 1)`, &object.Error{Message: "argument to `set` must be a HASH, got INTEGER"}},
+		{`set({}, fn() {}, 1)`, &object.Error{Message: "unusable as hash key: CLOSURE"}},
+		{`set({})`, &object.Error{Message: "wrong number of arguments. got=1, want=3"}},
+		{`is_error(error("ValueError", "bad input"))`, true},
+		{`is_error(1)`, false},
+		{`is_error(len(1))`, true},
+		{`let e = error("ValueError", "bad input"); error_kind(e)`, "ValueError"},
+		{`let e = error("ValueError", "bad input"); error_message(e)`, "bad input"},
+		{`error_kind(len(1))`, "TypeError"},
+		{`error_kind(first())`, "ArgumentError"},
+		{`let check = fn(x) { if (x < 0) { error("RangeError", "negative") } else { x } }; let r = check(-1); if (is_error(r)) { error_message(r) } else { r }`, "negative"},
+		{`error_message(error("Outer", "failed", error("Inner", "cause")))`, "failed"},
+		{`error(1, "a")`, &object.Error{Message: "argument to `error` must be a STRING, got INTEGER"}},
+		{`error("a", "b", 1)`, &object.Error{Message: "cause passed to `error` must be an ERROR, got INTEGER"}},
+		{`error("a")`, &object.Error{Message: "wrong number of arguments. got=1, want=2 or 3"}},
+		{`error_kind(1)`, &object.Error{Message: "argument to `error_kind` must be an ERROR, got INTEGER"}},
+		{`error_message(1)`, &object.Error{Message: "argument to `error_message` must be an ERROR, got INTEGER"}},
+		{`split("a,b,c", ",")`, []string{"a", "b", "c"}},
+		{`split("abc", "")`, []string{"a", "b", "c"}},
+		{`split("", ",")`, []string{""}},
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`join([], "-")`, ""},
+		{`join([1], "-")`, &object.Error{Message: "elements passed to `join` must be STRINGs, got INTEGER"}},
+		{`join("a", "-")`, &object.Error{Message: "argument to `join` must be an ARRAY, got STRING"}},
+		{`trim("  hi  ")`, "hi"},
+		{`upper("Hello")`, "HELLO"},
+		{`lower("Hello")`, "hello"},
+		{`upper(1)`, &object.Error{Message: "argument to `upper` must be a STRING, got INTEGER"}},
+		{`contains("monkey", "key")`, true},
+		{`contains("monkey", "donkey")`, false},
+		{`starts_with("monkey", "mon")`, true},
+		{`ends_with("monkey", "mon")`, false},
+		{`starts_with("monkey")`, &object.Error{Message: "wrong number of arguments. got=1, want=2"}},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`index_of("monkey", "key")`, 3},
+		{`index_of("monkey", "x")`, -1},
+		{`substr("monkey", 3)`, "key"},
+		{`substr("monkey", 1, 3)`, "onk"},
+		{`substr("monkey", 4, 10)`, "ey"},
+		{`substr("monkey", 6)`, ""},
+		{`substr("monkey", 7)`, &object.Error{Message: "start index 7 out of range for string of length 6"}},
+		{`substr("monkey", 1, -1)`, &object.Error{Message: "length passed to `substr` must not be negative, got -1"}},
+		{`substr("monkey", "1")`, &object.Error{Message: "argument to `substr` must be an INTEGER, got STRING"}},
+		{`len("héllo")`, 6},
+		{`index_of("héllo", "l")`, 3},
+		{`substr("héllo", 1, 2)`, "é"},
+		{`substr("héllo", index_of("héllo", "llo"))`, "llo"},
+		{`substr("héllo", 2)`, &object.Error{Message: "start index 2 passed to `substr` is inside a character"}},
+		{`substr("héllo", 1, 1)`, &object.Error{Message: "length 1 passed to `substr` ends inside a character"}},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", 0)`, ""},
+		{`repeat("ab", -1)`, &object.Error{Message: "count passed to `repeat` must not be negative, got -1"}},
+		{`repeat("ab", 9223372036854775807)`, &object.Error{Message: "result of `repeat` would be longer than 1073741824 bytes"}},
+		{`chars("héllo")`, []string{"h", "é", "l", "l", "o"}},
+		{`chars(1)`, &object.Error{Message: "argument to `chars` must be a STRING, got INTEGER"}},
+		{`keys({"b": 1, "a": 2, "c": 4})`, []string{"a", "b", "c"}},
+		{`keys({3: 1, -1: 2, 10: 3})`, []int{-1, 3, 10}},
+		{`values({"b": 1, "a": 2, "c": 4})`, []int{2, 1, 4}},
+		{`map(entries({"b": 1, "a": 2}), fn(e) { e[0] })`, []string{"a", "b"}},
+		{`map(entries({"b": 1, "a": 2}), fn(e) { e[1] })`, []int{2, 1}},
+		{`keys({})`, []int{}},
+		{`keys(1)`, &object.Error{Message: "argument to `keys` must be a HASH, got INTEGER"}},
+		{`has({"a": 1}, "a")`, true},
+		{`has({"a": 1}, "b")`, false},
+		{`has({[1, 2]: 1}, [1, 2])`, true},
+		{`has({}, len)`, &object.Error{Message: "unusable as hash key: BUILTIN"}},
+		{`get({"a": 1}, "a", 0)`, 1},
+		{`get({"a": 1}, "b", 0)`, 0},
+		{`get({"a": 1}, "b")`, nil},
+		{`get({"a": 1})`, &object.Error{Message: "wrong number of arguments. got=1, want=2 or 3"}},
+		{`delete({"a": 1, "b": 2}, "a")`, map[object.HashKey]int64{(&object.String{Value: "b"}).HashKey(): 2}},
+		{`delete({"a": 1}, "b")`, map[object.HashKey]int64{(&object.String{Value: "a"}).HashKey(): 1}},
+		{`let h = {"a": 1}; let d = delete(h, "a"); [len(keys(h)), len(keys(d))]`, []int{1, 0}},
+		{`delete({}, len)`, &object.Error{Message: "unusable as hash key: BUILTIN"}},
+		{`merge({1: 1, 2: 2}, {2: 3, 4: 4})`, map[object.HashKey]int64{
+			(&object.Integer{Value: 1}).HashKey(): 1,
+			(&object.Integer{Value: 2}).HashKey(): 3,
+			(&object.Integer{Value: 4}).HashKey(): 4,
+		}},
+		{`let h = {"a": 1}; let m = merge(h, {"a": 2}); h["a"]`, 1},
+		{`merge({}, 1)`, &object.Error{Message: "argument to `merge` must be a HASH, got INTEGER"}},
+		{`type(1)`, "INTEGER"},
+		{`type(92233720368547758070)`, "INTEGER"},
+		{`type("a")`, "STRING"},
+		{`type(true)`, "BOOLEAN"},
+		{`type(if (false) { 1 })`, "NULL"},
+		{`type([])`, "ARRAY"},
+		{`type({})`, "HASH"},
+		{`type(fn(x) { x })`, "FUNCTION"},
+		{`type(len)`, "BUILTIN"},
+		{`type(len(1))`, "ERROR"},
+		{`type()`, &object.Error{Message: "wrong number of arguments. got=0, want=1"}},
+		{`int("42")`, 42},
+		{`int(" -7 ")`, -7},
+		{`int("92233720368547758070")`, bigInt("92233720368547758070")},
+		{`int(5)`, 5},
+		{`int(true)`, 1},
+		{`int(false)`, 0},
+		{`int("4x")`, &object.Error{Message: "could not parse \"4x\" as an integer"}},
+		{`int([])`, &object.Error{Message: "argument to `int` not supported, got ARRAY"}},
+		{`str(42)`, "42"},
+		{`str("a")`, "a"},
+		{`str(true)`, "true"},
+		{`str([1, "a"])`, "[1, a]"},
+		{`str({"b": 2, "a": 1})`, "{a: 1, b: 2}"},
+		{`str(if (false) { 1 })`, "null"},
+		{`bool(1)`, true},
+		{`bool(0)`, true},
+		{`bool("")`, true},
+		{`bool(false)`, false},
+		{`bool(if (false) { 1 })`, false},
+		{`int(str(123)) == 123`, true},
+		{`json_parse("[1, 2, 3]")`, []int{1, 2, 3}},
+		{`json_parse(" true ")`, true},
+		{`json_parse("null")`, nil},
+		{`json_parse("[1,")`, &object.Error{Message: "invalid JSON: unexpected EOF"}},
+		{`json_parse(1)`, &object.Error{Message: "argument to `json_parse` must be a STRING, got INTEGER"}},
+		{`json_parse(json_stringify({"a": [1, 2]}))["a"]`, []int{1, 2}},
+		{`json_stringify([1, true, if (false) { 1 }])`, "[1,true,null]"},
+		{`json_stringify(["a", "b"])`, `["a","b"]`},
+		{`json_stringify({"b": 1, "a": {"c": []}})`, `{"a":{"c":[]},"b":1}`},
+		{`json_stringify([1], 2)`, "[\n  1\n]"},
+		{`json_stringify({1: 2})`, &object.Error{Message: "JSON object keys must be STRINGs, got INTEGER"}},
+		{`json_stringify([fn(x) { x }])`, &object.Error{Message: "cannot convert FUNCTION to JSON"}},
+		{`json_stringify(len)`, &object.Error{Message: "cannot convert BUILTIN to JSON"}},
+		{`json_stringify([1], -1)`, &object.Error{Message: "indent passed to `json_stringify` must be between 0 and 16, got -1"}},
+		{`random_int(3, 3)`, 3},
+		{`random_int(2, 1)`, &object.Error{Message: "empty range passed to `random_int`: 2 > 1"}},
+		{`random_int(1, "2")`, &object.Error{Message: "argument to `random_int` must be an INTEGER, got STRING"}},
+		{`sleep(-1)`, &object.Error{Message: "duration passed to `sleep` must not be negative, got -1"}},
+		{`sleep(0)`, nil},
+		{`shuffle(1)`, &object.Error{Message: "argument to `shuffle` must be an ARRAY, got INTEGER"}},
+		{`shuffle([])`, []int{}},
+		{`len(shuffle([1, 2, 3]))`, 3},
+		{`now(1)`, &object.Error{Message: "wrong number of arguments. got=1, want=0"}},
+		{`re_match("2024-10-19", "(\d+)-(\d+)")[2]`, "10"},
+		{`re_match("on 2024-10", "(?P<year>\d+)-(?P<month>\d+)")["year"]`, "2024"},
+		{`re_match("abc", "\d")`, nil},
+		{`re_match("ab", "a(x)?b")[1]`, nil},
+		{`len(keys(re_match("ab", "a(x)?b")))`, 2},
+		{`re_find_all("a1 b22 c333", "\d+")`, []string{"1", "22", "333"}},
+		{`re_find_all("abc", "\d")`, []string{}},
+		{`map(re_find_all("a=1, b=2", "(\w)=(\d)"), fn(m) { m[1] })`, []string{"a", "b"}},
+		{`re_replace("2024-10-19", "(\d+)-(\d+)-(\d+)", "$3/$2/$1")`, "19/10/2024"},
+		{`re_replace("a  b   c", " +", " ")`, "a b c"},
+		{`re_split("a, b;c", "[,;] *")`, []string{"a", "b", "c"}},
+		{`re_match("a", "(")`, &object.Error{Message: "invalid pattern passed to `re_match`: error parsing regexp: missing closing ): `(`"}},
+		{`re_split(1, "a")`, &object.Error{Message: "argument to `re_split` must be a STRING, got INTEGER"}},
+		{`re_replace("a", "a")`, &object.Error{Message: "wrong number of arguments. got=2, want=3"}},
+		{`map([1, 2, 3], fn(x) { x * 2 })`, []int{2, 4, 6}},
+		{`map([], fn(x) { x })`, []int{}},
+		{`let offset = 10; map([1, 2], fn(x) { x + offset })`, []int{11, 12}},
+		{`map([[1, 2], [3]], fn(xs) { reduce(map(xs, fn(x) { x * x }), 0, fn(acc, x) { acc + x }) })`, []int{5, 9}},
+		{`map([1, -2, 3], fn(x) { if (x < 0) { -x } else { x } })`, []int{1, 2, 3}},
+		{`map(["a", "bc"], len)`, []int{1, 2}},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, []int{3, 4}},
+		{`filter([1, 2, 3], fn(x) { if (x == 2) { true } })`, []int{2}},
+		{`reduce([1, 2, 3, 4], 0, fn(acc, x) { acc + x })`, 10},
+		{`reduce([], 7, fn(acc, x) { acc + x })`, 7},
+		{`each([1, 2], fn(x) { x })`, nil},
+		{`sort_by([3, 1, 2], fn(x) { x })`, []int{1, 2, 3}},
+		{`sort_by([1, 2, 3, 4], fn(x) { -x })`, []int{4, 3, 2, 1}},
+		{`sort_by([21, 12, 11, 22], fn(x) { if (x < 20) { 1 } else { 2 } })`, []int{12, 11, 21, 22}},
+		{`sort_by([3, 10, 2], fn(x) { if (x == 10) { "a" } else { "b" } })`, []int{10, 3, 2}},
+		{`sort_by([1, 2], fn(x) { if (x == 1) { "a" } else { 2 } })`, &object.Error{Message: "sort keys must all be INTEGERs or all be STRINGs"}},
+		{`sort_by([1], fn(x) { true })`, &object.Error{Message: "sort key must be an INTEGER or a STRING, got BOOLEAN"}},
+		{`map(1, fn(x) { x })`, &object.Error{Message: "argument to `map` must be an ARRAY, got INTEGER"}},
+		{`filter([1], 1)`, &object.Error{Message: "argument to `filter` must be a FUNCTION, got INTEGER"}},
+		{`reduce([1], fn(acc, x) { acc })`, &object.Error{Message: "wrong number of arguments. got=2, want=3"}},
 	}
 
 	runVmTest(t, tests)
@@ -443,6 +647,228 @@ fibonacci(15);`,
 	runVmTest(t, tests)
 }
 
+func TestTailCalls(t *testing.T) {
+	tests := []vmTestCase{
+		{
+			input: `
+let count = fn(n, acc) {
+	if (n == 0) { return acc; }
+	count(n - 1, acc + 1);
+};
+count(100000, 0);`,
+			expected: 100000,
+		},
+		{
+			input: `
+let countDown = fn(n) { if (n == 0) { true } else { countDown(n - 1) } };
+let start = fn(n) { countDown(n * 2) };
+start(10000);`,
+			expected: true,
+		},
+		{
+			input: `
+let wrapper = fn() {
+	let loop = fn(n) { if (n == 0) { return len("done"); } loop(n - 1) };
+	loop(5000) + 1;
+};
+wrapper();`,
+			expected: 5,
+		},
+		{
+			input: `
+let sum = fn(n) { if (n == 0) { 0 } else { n + sum(n - 1) } };
+sum(100);`,
+			expected: 5050,
+		},
+		{
+			// The macro puts the same call node in and out of tail position
+			input: `
+let dup = macro(e) { quote(fn() { let a = unquote(e); if (a > 100) { unquote(e) } else { 42 } }) };
+let f = fn(x) { x * 10 };
+dup(f(1))();`,
+			expected: 42,
+		},
+	}
+
+	runVmTest(t, tests)
+}
+
+func TestErrorStack(t *testing.T) {
+	t.Run("builtin error", func(t *testing.T) {
+		input := `let head = fn(x) { first(x) };
+let wrapper = fn(y) {
+	head(y) + 1
+};
+wrapper(5);`
+
+		comp := testutil.Compile(t, input)
+		machine := vm.New(comp.Bytecode())
+		err := machine.Run()
+
+		var runtimeErr *vm.RuntimeError
+		require.ErrorAs(t, err, &runtimeErr)
+		assert.EqualError(t, err, "TypeError: argument to `first` must be an ARRAY, got INTEGER")
+		assert.Equal(t, object.TypeError, runtimeErr.Object.Kind)
+		assert.Equal(t, object.Stack{
+			{Function: "first", Pos: token.Position{Line: 1, Column: 20}},
+			{Function: "head", Pos: token.Position{Line: 3, Column: 2}},
+			{Function: "wrapper", Pos: token.Position{Line: 5, Column: 1}},
+		}, runtimeErr.Stack)
+	})
+
+	t.Run("tail call", func(t *testing.T) {
+		input := "let g = fn(y) { y + true };\nlet h = fn(x) { g(x) };\nh(1);"
+		evaluated, ok := testutil.TestEval(t, input).(*object.Error)
+		require.True(t, ok)
+
+		comp := testutil.Compile(t, input)
+		machine := vm.New(comp.Bytecode())
+		err := machine.Run()
+
+		var runtimeErr *vm.RuntimeError
+		require.ErrorAs(t, err, &runtimeErr)
+		assert.Equal(t, object.Stack{
+			{Function: "g", Pos: token.Position{Line: 2, Column: 17}},
+		}, runtimeErr.Stack)
+		assert.Equal(t, evaluated.Stack, runtimeErr.Stack)
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		comp := testutil.Compile(t, `let add = fn(a, b) { a + b }; let twice = fn(x) { add(x, true) * 2 }; twice(1);`)
+		machine := vm.New(comp.Bytecode())
+		err := machine.Run()
+
+		var runtimeErr *vm.RuntimeError
+		require.ErrorAs(t, err, &runtimeErr)
+		assert.EqualError(t, err, "TypeError: unsupported types for binary operation: INTEGER BOOLEAN")
+		assert.Equal(t, object.Stack{
+			{Function: "add", Pos: token.Position{Line: 1, Column: 51}},
+			{Function: "twice", Pos: token.Position{Line: 1, Column: 71}},
+		}, runtimeErr.Stack)
+	})
+}
+
+func TestRunContext(t *testing.T) {
+	forever := `let loop = fn(n) { loop(n + 1) }; loop(0);`
+
+	t.Run("finishes within budget", func(t *testing.T) {
+		comp := testutil.Compile(t, `let add = fn(a, b) { a + b }; add(1, 2);`)
+		machine := vm.New(comp.Bytecode())
+		err := machine.RunContext(context.Background(), 1000)
+		require.NoError(t, err)
+		testutil.AssertIntegerObject(t, machine.LastPoppedStackElem(), 3)
+	})
+
+	t.Run("budget exceeded", func(t *testing.T) {
+		comp := testutil.Compile(t, forever)
+		machine := vm.New(comp.Bytecode())
+		err := machine.RunContext(context.Background(), 10000)
+		assert.ErrorIs(t, err, object.ErrBudgetExceeded)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		comp := testutil.Compile(t, forever)
+		machine := vm.New(comp.Bytecode())
+		err := machine.RunContext(ctx, 0)
+		assert.ErrorIs(t, err, object.ErrCancelled)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestForExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"let total = 0; for (i in range(1, 11)) { let total = total + i }; total", 55},
+		{"let x = 1; let x = x + 1; x", 2},
+		{"let f = fn() { let acc = []; for (i in range(9, 0, -2)) { let acc = push(acc, i) }; acc }; f()", []int{9, 7, 5, 3, 1}},
+		{`let acc = []; for (c in "héllo") { let acc = push(acc, c) }; acc`, []string{"h", "é", "l", "l", "o"}},
+		{`let acc = []; for (k in {"b": 2, "a": 1, "c": 3}) { let acc = push(acc, k) }; acc`, []string{"a", "b", "c"}},
+		{"let find = fn(xs, n) { for (x in xs) { if (x == n) { return true } }; false }; find([1, 2, 3], 2)", true},
+		{"let find = fn(xs, n) { for (x in xs) { if (x == n) { return true } }; false }; find([1, 2, 3], 4)", false},
+		{"let total = 0; for (x in [1, 2]) { for (y in [10, 20]) { let total = total + x * y } }; total", 90},
+		{"for (x in [1]) { x }", nil},
+		{"for (x in []) { x }", nil},
+		{"let f = fn() { let fs = []; for (i in range(3)) { let fs = push(fs, fn() { i }) }; map(fs, fn(g) { g() }) }; f()", []int{0, 1, 2}},
+		{"let fs = []; for (i in range(3)) { let fs = push(fs, fn() { i }) }; map(fs, fn(g) { g() })", []int{0, 1, 2}},
+		{"let fs = []; for (i in range(2)) { let fs = push(fs, fn() { fn() { i } }) }; map(fs, fn(g) { g()() })", []int{0, 1}},
+		{"let fs = []; for (i in range(2)) { for (j in range(2)) { let fs = push(fs, fn() { i * 10 + j }) } }; map(fs, fn(g) { g() })", []int{0, 1, 10, 11}},
+		{"for (i in range(3)) { i }; i", 2},
+		{"let f = fn() { for (i in range(3)) { i }; i }; f()", 2},
+		{"for (i in range(3)) { let i = i * 10 }; i", 20},
+		{"len(range(0, 9223372036854775807))", 9223372036854775807},
+		{"len(range(9223372036854775807, -9223372036854775807 - 1, -9223372036854775807 - 1))", 2},
+		{
+			"range(-1, 9223372036854775807)",
+			&object.Error{Kind: object.ArgumentError, Message: "range passed to `range` is too long: more than 9223372036854775807 values"},
+		},
+		{
+			"range(-9223372036854775807 - 1, 9223372036854775807)",
+			&object.Error{Kind: object.ArgumentError, Message: "range passed to `range` is too long: more than 9223372036854775807 values"},
+		},
+		{"len(range(0, 100, 7))", 15},
+		{"range(3) == range(0, 3, 1)", true},
+		{
+			"range(1, 2, 0)",
+			&object.Error{Kind: object.ArgumentError, Message: "step passed to `range` must not be zero"},
+		},
+	}
+
+	runVmTest(t, tests)
+
+	t.Run("not iterable", func(t *testing.T) {
+		comp := testutil.Compile(t, "let f = fn(x) { for (i in x) { i } };\nf(5);")
+		machine := vm.New(comp.Bytecode())
+		err := machine.Run()
+
+		var runtimeErr *vm.RuntimeError
+		require.ErrorAs(t, err, &runtimeErr)
+		assert.EqualError(t, err, "TypeError: cannot iterate over INTEGER")
+		assert.Equal(t, "f", runtimeErr.Stack[0].Function)
+	})
+}
+
+func TestMacros(t *testing.T) {
+	tests := []vmTestCase{
+		{
+			input: `
+let unless = macro(condition, consequence, alternative) {
+	quote(if (!(unquote(condition))) {
+		unquote(consequence);
+	} else {
+		unquote(alternative);
+	});
+};
+unless(10 > 5, 1, 2);`,
+			expected: 2,
+		},
+		{
+			input: `
+let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+reverse(1, 10) + reverse(2, 20);`,
+			expected: 27,
+		},
+	}
+
+	runVmTest(t, tests)
+}
+
+func TestMacroexpand(t *testing.T) {
+	input := `
+let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+macroexpand(reverse(1, 10 + 1));`
+
+	comp := testutil.Compile(t, input)
+	machine := vm.New(comp.Bytecode())
+	err := machine.Run()
+	require.NoError(t, err)
+
+	quote, ok := machine.LastPoppedStackElem().(*object.Quote)
+	require.Truef(t, ok, "expected *object.Quote, got %T", machine.LastPoppedStackElem())
+	assert.Equal(t, "((10 + 1) - 1)", quote.Node.String())
+}
+
 type vmTestCase struct {
 	input    string
 	expected any
@@ -473,3 +899,312 @@ func runVmTest(t *testing.T, tests []vmTestCase) {
 		})
 	}
 }
+
+func TestCallbackErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		stack    object.Stack
+	}{
+		{
+			"let f = fn(x) { x + true };\nmap([1, 2], f);",
+			"TypeError: unsupported types for binary operation: INTEGER BOOLEAN",
+			object.Stack{
+				{Function: "f", Pos: token.Position{Line: 2, Column: 1}},
+			},
+		},
+		{
+			"map([1], fn(x, y) { x });",
+			"ArgumentError: wrong number of arguments: want=2, got=1",
+			object.Stack{},
+		},
+		{
+			"let f = fn(x) { filter([x], fn(y) { y() }) };\nmap([1], f);",
+			"TypeError: calling non-closure and non-built-in",
+			object.Stack{
+				{Function: "", Pos: token.Position{Line: 1, Column: 17}},
+				{Function: "f", Pos: token.Position{Line: 2, Column: 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			comp := testutil.Compile(t, tt.input)
+			machine := vm.New(comp.Bytecode())
+			err := machine.Run()
+
+			var runtimeErr *vm.RuntimeError
+			require.ErrorAs(t, err, &runtimeErr)
+			assert.EqualError(t, err, tt.expected)
+			assert.Equal(t, tt.stack, runtimeErr.Stack)
+		})
+	}
+}
+
+func TestIO(t *testing.T) {
+	input := `puts(1, "a");
+print("b", 2);
+eprint("oops");
+let first = read_line();
+print(first);
+read_line();
+read_line();`
+
+	var stdout, stderr bytes.Buffer
+	opts := vm.DefaultOptions()
+	opts.IO = object.IO{Stdout: &stdout, Stderr: &stderr, Stdin: strings.NewReader("x\ny\n")}
+
+	comp := testutil.Compile(t, input)
+	machine := vm.NewWithOptions(comp.Bytecode(), opts)
+	require.NoError(t, machine.Run())
+
+	assert.Equal(t, "1\na\nb2x", stdout.String())
+	assert.Equal(t, "oops\n", stderr.String())
+	testutil.AssertNullObject(t, machine.LastPoppedStackElem())
+}
+
+func TestFileBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	input := fmt.Sprintf(`let dir = "%s";
+write_file(dir + "/b.txt", "hello");
+write_file(dir + "/a.txt", upper(read_file(dir + "/b.txt")));
+let listed = list_dir(dir);
+remove(dir + "/b.txt");
+[listed, list_dir(dir), exists(dir + "/b.txt"), read_file(dir + "/a.txt"), is_error(read_file(dir + "/b.txt"))]`, dir)
+
+	t.Run("allowed", func(t *testing.T) {
+		opts := vm.DefaultOptions()
+		opts.Files = object.FilePolicy{Roots: []string{dir}}
+
+		comp := testutil.Compile(t, input)
+		machine := vm.NewWithOptions(comp.Bytecode(), opts)
+		require.NoError(t, machine.Run())
+
+		result := machine.LastPoppedStackElem()
+		assert.Equal(t, "[[a.txt, b.txt], [a.txt], false, HELLO, true]", result.Inspect())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		comp := testutil.Compile(t, input)
+		machine := vm.New(comp.Bytecode())
+		require.NoError(t, machine.Run())
+
+		result := machine.LastPoppedStackElem()
+		assert.Equal(t, "[ERROR: PermissionError: `list_dir` is not allowed: file access is disabled, ERROR: PermissionError: `list_dir` is not allowed: file access is disabled, ERROR: PermissionError: `exists` is not allowed: file access is disabled, ERROR: PermissionError: `read_file` is not allowed: file access is disabled, true]", result.Inspect())
+	})
+}
+
+func TestTimeAndRandom(t *testing.T) {
+	input := `let start = now();
+sleep(1500);
+let rolls = map([1, 2, 3, 4, 5, 6, 7, 8], fn(x) { random_int(1, 6) });
+[now() - start, now(), len(filter(rolls, fn(r) { if (r < 1) { true } else { r > 6 } })), shuffle([1, 2, 3, 4, 5])]`
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	run := func() string {
+		opts := vm.DefaultOptions()
+		opts.Clock = object.NewFixedClock(start)
+		opts.Random = object.NewSeededRandom(7)
+
+		comp := testutil.Compile(t, input)
+		machine := vm.NewWithOptions(comp.Bytecode(), opts)
+		require.NoError(t, machine.Run())
+
+		result := machine.LastPoppedStackElem().(*object.Array)
+		testutil.AssertIntegerObject(t, result.At(0), 1500)
+		testutil.AssertIntegerObject(t, result.At(1), start.UnixMilli()+1500)
+		testutil.AssertIntegerObject(t, result.At(2), 0)
+		return result.Inspect()
+	}
+
+	assert.Equal(t, run(), run(), "runs with the same seed should match")
+}
+
+func TestOverflow(t *testing.T) {
+	t.Run("call depth", func(t *testing.T) {
+		comp := testutil.Compile(t, `let deep = fn() { deep() + 1 }; deep();`)
+		machine := vm.New(comp.Bytecode())
+		err := machine.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "maximum call depth of 1024 exceeded calling deep")
+	})
+
+	t.Run("stack size", func(t *testing.T) {
+		comp := testutil.Compile(t, `let deep = fn(a, b, c) { deep(a, b, c) + 1 }; deep(1, 2, 3);`)
+		machine := vm.New(comp.Bytecode())
+		err := machine.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "stack overflow calling deep")
+	})
+
+	t.Run("internal panic", func(t *testing.T) {
+		machine := vm.New(&compiler.Bytecode{
+			Instructions: code.Make(code.OpConstant, 5),
+		})
+		err := machine.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "internal error executing OpConstant at ip 0")
+	})
+}
+
+func TestNewWithOptions(t *testing.T) {
+	input := `
+let sum = fn(n) { if (n == 0) { 0 } else { n + sum(n - 1) } };
+sum(200);
+`
+
+	t.Run("grows on demand", func(t *testing.T) {
+		comp := testutil.Compile(t, input)
+		machine := vm.NewWithOptions(comp.Bytecode(), vm.Options{Growth: vm.GrowOnDemand})
+		require.NoError(t, machine.Run())
+		testutil.AssertIntegerObject(t, machine.LastPoppedStackElem(), 20100)
+		assert.Less(t, len(machine.Globals()), vm.GlobalsSize)
+	})
+
+	t.Run("preallocate", func(t *testing.T) {
+		comp := testutil.Compile(t, input)
+		machine := vm.NewWithOptions(comp.Bytecode(), vm.Options{Growth: vm.Preallocate})
+		require.NoError(t, machine.Run())
+		testutil.AssertIntegerObject(t, machine.LastPoppedStackElem(), 20100)
+		assert.Len(t, machine.Globals(), vm.GlobalsSize)
+	})
+
+	t.Run("max frames", func(t *testing.T) {
+		comp := testutil.Compile(t, input)
+		machine := vm.NewWithOptions(comp.Bytecode(), vm.Options{MaxFrames: 100})
+		err := machine.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "maximum call depth of 100 exceeded calling sum")
+	})
+
+	t.Run("stack size", func(t *testing.T) {
+		comp := testutil.Compile(t, input)
+		machine := vm.NewWithOptions(comp.Bytecode(), vm.Options{StackSize: 100})
+		err := machine.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "stack overflow in sum")
+	})
+
+	t.Run("globals size", func(t *testing.T) {
+		comp := testutil.Compile(t, `let a = 1; let b = 2; let c = 3;`)
+		machine := vm.NewWithOptions(comp.Bytecode(), vm.Options{GlobalsSize: 2})
+		err := machine.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "too many globals: limit is 2")
+	})
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775806 + 1", 9223372036854775807},
+		{"9223372036854775807 + 1", "ArithmeticError: integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", "ArithmeticError: integer overflow: -9223372036854775807 - 2"},
+		{"4611686018427387904 * 2", "ArithmeticError: integer overflow: 4611686018427387904 * 2"},
+		{"let min = -9223372036854775807 - 1; -min", "ArithmeticError: integer overflow: -(-9223372036854775808)"},
+		{"let min = -9223372036854775807 - 1; min / -1", "ArithmeticError: integer overflow: -9223372036854775808 / -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			comp := testutil.Compile(t, tt.input)
+			machine := vm.NewWithOptions(comp.Bytecode(), vm.Options{Arithmetic: object.CheckedArithmetic})
+			err := machine.Run()
+
+			switch expected := tt.expected.(type) {
+			case int:
+				require.NoError(t, err)
+				testutil.AssertIntegerObject(t, machine.LastPoppedStackElem(), int64(expected))
+			case string:
+				require.Error(t, err)
+				assert.ErrorIs(t, err, object.ErrIntegerOverflow)
+				assert.Equal(t, expected, err.Error())
+			}
+		})
+	}
+
+	t.Run("wrapping", func(t *testing.T) {
+		comp := testutil.Compile(t, "9223372036854775807 + 1")
+		machine := vm.NewWithOptions(comp.Bytecode(), vm.Options{Arithmetic: object.WrappingArithmetic})
+		require.NoError(t, machine.Run())
+		testutil.AssertIntegerObject(t, machine.LastPoppedStackElem(), -9223372036854775808)
+	})
+}
+
+func TestBigIntegers(t *testing.T) {
+	tests := []vmTestCase{
+		{"9223372036854775807 + 1", bigInt("9223372036854775808")},
+		{"-9223372036854775807 - 2", bigInt("-9223372036854775809")},
+		{"4611686018427387904 * 4", bigInt("18446744073709551616")},
+		{"let min = -9223372036854775807 - 1; -min", bigInt("9223372036854775808")},
+		{"123456789012345678901234567890", bigInt("123456789012345678901234567890")},
+		{"123456789012345678901234567890 / 10", bigInt("12345678901234567890123456789")},
+		{"9223372036854775808 - 1", 9223372036854775807},
+		{"-(9223372036854775807 + 1) / 2", -4611686018427387904},
+		{"9223372036854775808 > 9223372036854775807", true},
+		{"9223372036854775807 < 9223372036854775808", true},
+		{"9223372036854775808 == 9223372036854775807 + 1", true},
+		{"9223372036854775808 != 1", true},
+		{`{9223372036854775808: "big"}[9223372036854775807 + 1]`, "big"},
+	}
+
+	runVmTest(t, tests)
+}
+
+func bigInt(value string) *big.Int {
+	n, _ := new(big.Int).SetString(value, 10)
+	return n
+}
+
+func TestErrorsMatchEvaluator(t *testing.T) {
+	tests := []string{
+		"first(1) + 1",
+		"for (x in 5) { x }",
+		"fn(x) { x }()",
+		"let f = fn(x) { 10 / x }; f(0)",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			evaluated := testutil.TestEval(t, input)
+			evalErr, ok := evaluated.(*object.Error)
+			require.True(t, ok, "evaluator didn't fail, got %s", evaluated.Inspect())
+
+			comp := testutil.Compile(t, input)
+			machine := vm.New(comp.Bytecode())
+			err := machine.Run()
+
+			var runtimeErr *vm.RuntimeError
+			require.ErrorAs(t, err, &runtimeErr)
+			assert.Equal(t, evalErr.Error(), err.Error())
+			assert.Equal(t, evalErr.Kind, runtimeErr.Object.Kind)
+		})
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	comp := testutil.Compile(t, "let f = fn(x) { 10 / x }; f(0)")
+	machine := vm.New(comp.Bytecode())
+	err := machine.Run()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, object.ErrDivisionByZero)
+	assert.Equal(t, "ArithmeticError: division by zero: 10 / 0", err.Error())
+
+	var runtimeErr *vm.RuntimeError
+	require.ErrorAs(t, err, &runtimeErr)
+	assert.Equal(t, "f", runtimeErr.Stack[0].Function)
+}
+
+func TestErrorValues(t *testing.T) {
+	comp := testutil.Compile(t, "let e = len(1);\ne")
+	machine := vm.New(comp.Bytecode())
+	require.NoError(t, machine.Run())
+
+	errObj, ok := machine.LastPoppedStackElem().(*object.Error)
+	require.Truef(t, ok, "object is not an Error, got %T", machine.LastPoppedStackElem())
+	assert.Equal(t, object.TypeError, errObj.Kind)
+	assert.Equal(t, token.Position{Line: 1, Column: 9}, errObj.Pos)
+}